@@ -74,5 +74,5 @@ func startApp(ctx context.Context) (domain.Agent, error) {
 		return nil, fmt.Errorf("unsupported provider: %s", cfg.DefaultProvider)
 	}
 
-	return agent.NewAgent(ctx, chatModel)
+	return agent.NewAgent(ctx, chatModel, agent.Mode{})
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/antunesgabriel/how/infrastructure/audit"
+)
+
+// handleAudit prints the commands recorded in the audit log. Usage: how audit [flags]
+func handleAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	since := fs.String("since", "", "only show commands executed after this time. Exe: 24h, 7d, 2025-01-31")
+	session := fs.String("session", "", "only show commands executed in this session")
+	contains := fs.String("grep", "", "only show commands containing this text")
+	failed := fs.Bool("failed", false, "only show commands that exited with a non-zero code")
+	limit := fs.Int("limit", 50, "maximum number of commands to show, 0 for no limit")
+	asJSON := fs.Bool("json", false, "print the entries as JSON Lines")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q, usage: how audit [flags]", fs.Arg(0))
+	}
+
+	filter := audit.Filter{
+		SessionID: *session,
+		Contains:  *contains,
+		Failed:    *failed,
+		Limit:     *limit,
+	}
+
+	if *since != "" {
+		sinceTime, err := parseSince(*since, time.Now())
+		if err != nil {
+			return err
+		}
+		filter.Since = sinceTime
+	}
+
+	entries, err := audit.NewLog(audit.DefaultPath()).Query(filter)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No commands found in the audit log.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tUSER\tEXIT\tDURATION\tSESSION\tCWD\tCOMMAND")
+	for _, entry := range entries {
+		sessionID := entry.SessionID
+		if len(sessionID) > 8 {
+			sessionID = sessionID[:8]
+		}
+
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			entry.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			entry.User,
+			entry.ExitCode,
+			time.Duration(entry.DurationMs)*time.Millisecond,
+			sessionID,
+			entry.Cwd,
+			strings.ReplaceAll(entry.Command, "\n", " "),
+		)
+	}
+
+	return w.Flush()
+}
+
+// parseSince parses a relative duration such as "24h" or "7d", or a date such as "2025-01-31"
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since value %q, use a duration like 24h or 7d, or a date like 2025-01-31", value)
+}
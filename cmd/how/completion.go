@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/infrastructure/audit"
+	"github.com/antunesgabriel/how/infrastructure/session"
+	"github.com/antunesgabriel/how/infrastructure/shell"
+)
+
+// commands are the subcommands of the CLI with their descriptions, used by the completion scripts
+var commands = [][2]string{
+	{"init", "Create a default configuration"},
+	{"audit", "Show the commands executed from the chat"},
+	{"usage", "Show the tokens and cost used by the sessions"},
+	{"search", "Search the messages and commands of past sessions"},
+	{"policy", "Check which policy rule applies to a command"},
+	{"shell-init", "Print the shell integration script"},
+	{"fix", "Explain and fix the last failed command"},
+	{"suggest", "Suggest a command for a request"},
+	{"completion", "Print the shell completion script"},
+}
+
+// maxCompletedSessions is the number of most recent session ids offered for completion
+const maxCompletedSessions = 50
+
+// handleCompletion prints the completion script of a shell. Usage: how completion bash|zsh|fish
+func handleCompletion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: how completion %s", strings.Join(shell.Supported, "|"))
+	}
+
+	script, err := shell.CompletionScript(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(script)
+	return nil
+}
+
+// handleComplete prints the dynamic values used by the completion scripts, one per line.
+// Errors are ignored, since completion must never break the shell. Usage: how __complete <kind>
+func handleComplete(args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	switch args[0] {
+	case "commands":
+		describe := slices.Contains(args[1:], "--describe")
+		for _, command := range commands {
+			if describe {
+				fmt.Printf("%s:%s\n", command[0], command[1])
+				continue
+			}
+			fmt.Println(command[0])
+		}
+	case "profiles":
+		cfg, err := config.Read()
+		if err != nil {
+			return
+		}
+
+		names := make([]string, 0, len(cfg.Profiles))
+		for name := range cfg.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	case "modes":
+		cfg, err := config.Read()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+
+		for _, name := range cfg.ModeNames() {
+			fmt.Println(name)
+		}
+	case "providers":
+		cfg, err := config.Read()
+		if err != nil {
+			return
+		}
+
+		for _, provider := range cfg.ConfiguredProviders() {
+			fmt.Println(provider)
+		}
+	case "sessions":
+		seen := map[string]bool{}
+
+		sessions, _ := session.NewStore(session.DefaultDirPath()).List()
+		for _, s := range sessions {
+			if len(seen) >= maxCompletedSessions {
+				return
+			}
+
+			seen[s.ID] = true
+			fmt.Println(s.ID)
+		}
+
+		// The audit log also has the sessions that ran commands but are not saved, such as deleted ones
+		entries, err := audit.NewLog(audit.DefaultPath()).Query(audit.Filter{})
+		if err != nil {
+			return
+		}
+
+		for idx := len(entries) - 1; idx >= 0 && len(seen) < maxCompletedSessions; idx-- {
+			sessionID := entries[idx].SessionID
+			if sessionID == "" || seen[sessionID] {
+				continue
+			}
+
+			seen[sessionID] = true
+			fmt.Println(sessionID)
+		}
+	}
+}
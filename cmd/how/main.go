@@ -2,32 +2,68 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	einomodel "github.com/cloudwego/eino/components/model"
 
 	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+	"github.com/antunesgabriel/how/infrastructure/audit"
+	"github.com/antunesgabriel/how/infrastructure/clipboard"
+	"github.com/antunesgabriel/how/infrastructure/command"
+	"github.com/antunesgabriel/how/infrastructure/environment"
+	"github.com/antunesgabriel/how/infrastructure/history"
 	"github.com/antunesgabriel/how/infrastructure/orchestration/agent"
 	llmodel "github.com/antunesgabriel/how/infrastructure/orchestration/model"
+	"github.com/antunesgabriel/how/infrastructure/prompt"
+	"github.com/antunesgabriel/how/infrastructure/sandbox"
+	"github.com/antunesgabriel/how/infrastructure/search"
+	"github.com/antunesgabriel/how/infrastructure/session"
+	"github.com/antunesgabriel/how/infrastructure/shell"
 	"github.com/antunesgabriel/how/presetation"
 )
 
 var (
 	provider = "" // Provider to use. Exe: openai, claude, gemini, deepseek, ollama
 	model    = "" // Provider model to use. Exe: gpt-4o, gpt-3.5-turbo, etc.
+	profile  = "" // Profile to use. Exe: production. Can also be set with HOW_PROFILE
+	mode     = "" // Agent mode to use. Exe: explain, generate, debug, review
+	resume   = "" // Session to continue, optionally at a message. Exe: 3f2a1b2c or 3f2a1b2c:12
 )
 
 func main() {
 	ctx := context.Background()
 
-	if len(os.Args) > 1 {
-		cmd := os.Args[1]
+	args := os.Args[1:]
+	if value, rest, ok := extractFlag(args, "--provider"); ok {
+		provider, args = value, rest
+	}
+	if value, rest, ok := extractFlag(args, "--model"); ok {
+		model, args = value, rest
+	}
+	if value, rest, ok := extractFlag(args, "--mode"); ok {
+		mode, args = value, rest
+	}
+	if value, rest, ok := extractFlag(args, "--resume"); ok {
+		resume, args = value, rest
+	}
+	if value, rest, ok := extractFlag(args, "--profile"); ok {
+		profile, args = value, rest
+	} else if env := os.Getenv("HOW_PROFILE"); env != "" {
+		profile = env
+	}
+
+	if len(args) > 0 && runsSubcommand(args) {
+		cmd := args[0]
 
 		if cmd == "init" {
-			isLocal := slices.Contains(os.Args[2:], "--local")
+			isLocal := slices.Contains(args[1:], "--local")
 
 			if err := handleInit(isLocal); err != nil {
 				fmt.Printf("Error: %v\n", err)
@@ -36,20 +72,163 @@ func main() {
 			return
 		}
 
-		query := strings.Join(os.Args[1:], " ")
-		if err := startApp(ctx, query); err != nil {
-			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+		if cmd == "audit" {
+			if err := handleAudit(args[1:]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if cmd == "usage" {
+			if err := handleUsage(args[1:]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if cmd == "search" {
+			if err := handleSearch(args[1:]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if cmd == "shell-init" {
+			if err := handleShellInit(args[1:]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if cmd == "fix" {
+			if err := handleFix(ctx, args[1:]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if cmd == "suggest" {
+			if err := handleSuggest(ctx, args[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+				var agentErr *domain.AgentError
+				if errors.As(err, &agentErr) && agentErr.Hint != "" {
+					fmt.Fprintf(os.Stderr, "Hint: %s\n", agentErr.Hint)
+				}
+				os.Exit(1)
+			}
+			return
+		}
+
+		if cmd == "completion" {
+			if err := handleCompletion(args[1:]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if cmd == "__complete" {
+			handleComplete(args[1:])
+			return
+		}
+
+		if cmd == "policy" {
+			if err := handlePolicy(args[1:]); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		}
-		return
 	}
 
-	if err := startApp(ctx, ""); err != nil {
+	// A leading "--" makes the rest a question, even when it starts with the name of a subcommand
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	if err := startApp(ctx, strings.Join(args, " "), mode); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// runsSubcommand reports whether the first word names a subcommand and the rest are arguments it accepts.
+// Otherwise the words are a question, so "how search ffmpeg" searches the sessions and "how usage of tar"
+// is asked to the model. The subcommands taking free text, such as search and fix, always run
+func runsSubcommand(args []string) bool {
+	rest := args[1:]
+
+	switch args[0] {
+	case "search", "fix", "suggest", "__complete":
+		return true
+	case "init", "audit", "usage":
+		return flagArgs(rest)
+	case "shell-init", "completion":
+		return shellArgs(rest)
+	case "policy":
+		return len(rest) == 0 || rest[0] == "test"
+	}
+
+	return false
+}
+
+// flagArgs reports whether the arguments of a subcommand are only flags, so "how usage --by day" runs the
+// subcommand and "how usage of tar" is a question
+func flagArgs(args []string) bool {
+	return len(args) == 0 || strings.HasPrefix(args[0], "-")
+}
+
+// shellArgs reports whether the arguments of a subcommand are a supported shell, so "how completion zsh"
+// runs the subcommand and "how completion of git commands" is a question
+func shellArgs(args []string) bool {
+	return len(args) == 0 || (len(args) == 1 && slices.Contains(shell.Supported, args[0]))
+}
+
+// extractFlag removes a "--name value" or "--name=value" flag from args and returns its value.
+// The arguments after "--" are a question and are left as they are
+func extractFlag(args []string, name string) (string, []string, bool) {
+	for idx, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		if value, ok := strings.CutPrefix(arg, name+"="); ok {
+			return value, slices.Delete(slices.Clone(args), idx, idx+1), true
+		}
+
+		if arg == name && idx+1 < len(args) {
+			return args[idx+1], slices.Delete(slices.Clone(args), idx, idx+2), true
+		}
+	}
+
+	return "", args, false
+}
+
+// loadConfig loads the configuration and selects the profile given by flag or environment
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(provider, model)
+	if err != nil {
+		if strings.Contains(err.Error(), "config file not found") {
+			fmt.Fprintln(os.Stderr, "Configuration file not found.")
+			fmt.Fprintln(os.Stderr, "Run 'how init' to create a default configuration.")
+			return nil, fmt.Errorf("configuration required")
+		}
+		return nil, err
+	}
+
+	if err := cfg.UseProfile(profile); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
 func handleInit(isLocal bool) error {
 	var configPath string
 	var createConfigFunc func() error
@@ -78,38 +257,278 @@ func handleInit(isLocal bool) error {
 	return nil
 }
 
-func startApp(ctx context.Context, query string) error {
-	cfg, err := config.Load(provider, model)
+func startApp(ctx context.Context, query, modeName string) error {
+	cfg, err := loadConfig()
 	if err != nil {
-		if strings.Contains(err.Error(), "config file not found") {
-			fmt.Println("Configuration file not found.")
-			fmt.Println("Run 'how init' to create a default configuration.")
-			return fmt.Errorf("configuration required")
+		return err
+	}
+
+	policy, err := command.NewPolicy(cfg.ActivePolicy())
+	if err != nil {
+		return err
+	}
+
+	chatModel, err := newChatModel(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	prompts, err := prompt.NewLibrary(cfg.Prompts, config.PromptsDirPath())
+	if err != nil {
+		return err
+	}
+
+	agentMode, promptName, err := resolveMode(cfg, prompts, modeName)
+	if err != nil {
+		return err
+	}
+
+	theme, err := newTheme(cfg)
+	if err != nil {
+		return err
+	}
+
+	keys, err := newKeyMap(cfg)
+	if err != nil {
+		return err
+	}
+
+	titleModel, err := newTitleModel(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	sessions := session.NewStore(session.DefaultDirPath())
+
+	broker := presetation.NewCommandBroker()
+
+	llmAgent, err := agent.NewAgent(
+		ctx,
+		chatModel,
+		agentMode,
+		agent.WithCommandRunner(broker),
+		agent.WithEnvironment(newEnvironment(cfg)),
+		agent.WithUsage(cfg.DefaultProvider, cfg.CurrentModel(), cfg),
+		agent.WithContextLimits(agent.ContextLimits{
+			MaxTokens:        cfg.ContextBudget(),
+			ToolOutputTokens: cfg.ToolOutputBudget(),
+			Summarize:        cfg.Context != nil && cfg.Context.Summarize,
+		}),
+		agent.WithTitleModel(titleModel),
+	)
+	if err != nil {
+		return err
+	}
+
+	terminal := presetation.NewTerminal(os.Stdout)
+	opts := []presetation.Option{
+		presetation.WithTerminal(terminal),
+		presetation.WithAnalyzer(command.NewAnalyzer()),
+		presetation.WithPolicy(policy),
+		presetation.WithAuditLogger(audit.NewLog(audit.DefaultPath())),
+		presetation.WithSandbox(sandbox.NewSandbox(time.Minute)),
+		presetation.WithCommandBroker(broker),
+		presetation.WithPrompts(prompts, promptName),
+		presetation.WithSessionStore(sessions),
+		presetation.WithSearcher(search.NewSearcher(session.DefaultDirPath(), audit.DefaultPath())),
+		presetation.WithProvider(string(cfg.DefaultProvider), cfg.CurrentModel()),
+		presetation.WithHistory(history.NewFile(history.DefaultFilePath())),
+		presetation.WithClipboard(clipboard.NewClipboard(terminal)),
+		presetation.WithTheme(theme),
+		presetation.WithKeyMap(keys),
+		presetation.WithSessionTitler(newSessionTitler(cfg, llmAgent)),
+	}
+
+	if resume != "" {
+		resumed, focus, err := resumeSession(sessions, resume)
+		if err != nil {
+			return err
 		}
+		opts = append(opts, presetation.WithSession(resumed, focus))
+	}
+
+	if err := presetation.StartApp(llmAgent, query, opts...); err != nil {
 		return err
 	}
 
-	var chatModel einomodel.ToolCallingChatModel
+	return nil
+}
+
+// resumeSession loads the session to continue from a "<id>[:<message>]" value. Without a message
+// the conversation is shown from its end
+func resumeSession(store *session.Store, value string) (domain.Session, int, error) {
+	id, at, hasMessage := strings.Cut(value, ":")
+
+	resumed, err := store.Load(id)
+	if err != nil {
+		return domain.Session{}, 0, err
+	}
+
+	if !hasMessage {
+		return resumed, -1, nil
+	}
+
+	focus, err := strconv.Atoi(at)
+	if err != nil || focus < 0 || focus >= len(resumed.Messages) {
+		return domain.Session{}, 0, fmt.Errorf("invalid message %q, session %s has %d messages", at, id, len(resumed.Messages))
+	}
+
+	return resumed, focus, nil
+}
+
+// newSessionTitler returns what titles the sessions following the configuration, nil when they are not titled
+func newSessionTitler(cfg *config.Config, llmAgent *agent.Agent) domain.SessionTitler {
+	switch cfg.SessionTitles() {
+	case config.SessionTitlesOff:
+		return nil
+	case config.SessionTitlesHeuristic:
+		return agent.LocalTitler{}
+	}
 
-	switch cfg.DefaultProvider {
+	return llmAgent
+}
+
+// newTitleModel returns the model of the default provider the sessions are titled with, nil when
+// the titles are written by the chat model
+func newTitleModel(ctx context.Context, cfg *config.Config) (einomodel.ToolCallingChatModel, error) {
+	name := cfg.SessionTitleModel()
+	if name == "" || cfg.SessionTitles() != config.SessionTitlesModel {
+		return nil, nil
+	}
+
+	chatModel, err := newProviderModel(ctx, cfg.WithModel(name), cfg.DefaultProvider)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the %s title model: %w", cfg.DefaultProvider, err)
+	}
+
+	// A single candidate, so the usage is labelled with the title model
+	attempts, initialBackoff, maxBackoff := cfg.RetryPolicy()
+	return llmodel.NewFallbackModel(llmodel.RetryPolicy{
+		MaxAttempts:    attempts,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+	}, llmodel.Candidate{
+		Provider:  string(cfg.DefaultProvider),
+		Model:     name,
+		ChatModel: chatModel,
+	}), nil
+}
+
+// resolveMode builds the agent mode selected by name, or by the configuration when name is empty,
+// and returns it with the name of its system prompt
+func resolveMode(cfg *config.Config, prompts *prompt.Library, name string) (agent.Mode, string, error) {
+	modeCfg, err := cfg.ResolveMode(name)
+	if err != nil {
+		return agent.Mode{}, "", err
+	}
+
+	systemPrompt, err := prompts.Render(modeCfg.Prompt)
+	if err != nil {
+		return agent.Mode{}, "", err
+	}
+
+	return agent.Mode{
+		SystemPrompt: systemPrompt,
+		Tools:        modeCfg.Tools,
+		Output:       modeCfg.Output,
+	}, modeCfg.Prompt, nil
+}
+
+// newTheme returns the colours of the theme selected in the configuration, over the ones of its base theme
+func newTheme(cfg *config.Config) (presetation.Theme, error) {
+	resolved, err := cfg.ResolveTheme()
+	if err != nil {
+		return presetation.Theme{}, err
+	}
+
+	theme := presetation.BuiltinTheme(resolved.Base)
+
+	for _, override := range []struct {
+		value  string
+		target *string
+	}{
+		{resolved.Title, &theme.Title},
+		{resolved.Info, &theme.Info},
+		{resolved.Prompt, &theme.Prompt},
+		{resolved.User, &theme.User},
+		{resolved.Assistant, &theme.Assistant},
+		{resolved.Command, &theme.Command},
+		{resolved.CommandBackground, &theme.CommandBackground},
+		{resolved.Error, &theme.Error},
+		{resolved.Warning, &theme.Warning},
+		{resolved.Status, &theme.Status},
+		{resolved.Confirm, &theme.Confirm},
+		{resolved.ConfirmBackground, &theme.ConfirmBackground},
+		{resolved.Markdown, &theme.Markdown},
+	} {
+		if override.value != "" {
+			*override.target = override.value
+		}
+	}
+
+	return theme, nil
+}
+
+// newKeyMap returns the key bindings of the preset selected in the configuration with its overrides
+func newKeyMap(cfg *config.Config) (presetation.KeyMap, error) {
+	if cfg.Keys == nil {
+		return presetation.DefaultKeyMap(), nil
+	}
+
+	return presetation.NewKeyMap(cfg.Keys.Preset, cfg.Keys.Bindings)
+}
+
+// newEnvironment returns the collector describing the machine in the system prompt, or nil when it is disabled
+func newEnvironment(cfg *config.Config) domain.Environment {
+	collector := environment.NewCollector(cfg.Environment)
+	if collector == nil {
+		return nil
+	}
+
+	return collector
+}
+
+// newChatModel creates the model of the default provider followed by the fallback providers,
+// retrying transient errors with each of them
+func newChatModel(ctx context.Context, cfg *config.Config) (einomodel.ToolCallingChatModel, error) {
+	providers := append([]config.Provider{cfg.DefaultProvider}, cfg.Fallback...)
+	candidates := make([]llmodel.Candidate, 0, len(providers))
+
+	for _, provider := range providers {
+		chatModel, err := newProviderModel(ctx, cfg, provider)
+		if err != nil {
+			return nil, fmt.Errorf("error creating %s model: %w", provider, err)
+		}
+
+		candidates = append(candidates, llmodel.Candidate{
+			Provider:  string(provider),
+			Model:     cfg.ProviderModel(provider),
+			ChatModel: chatModel,
+		})
+	}
+
+	attempts, initialBackoff, maxBackoff := cfg.RetryPolicy()
+
+	return llmodel.NewFallbackModel(llmodel.RetryPolicy{
+		MaxAttempts:    attempts,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+	}, candidates...), nil
+}
+
+func newProviderModel(ctx context.Context, cfg *config.Config, provider config.Provider) (einomodel.ToolCallingChatModel, error) {
+	switch provider {
 	case config.ProviderOpenAI:
-		chatModel, err = llmodel.NewOpenAIModel(ctx, cfg)
+		return llmodel.NewOpenAIModel(ctx, cfg)
 	case config.ProviderGemini:
-		chatModel, err = llmodel.NewGeminiModel(ctx, cfg)
+		return llmodel.NewGeminiModel(ctx, cfg)
 	case config.ProviderClaude:
-		chatModel, err = llmodel.NewClaudeModel(ctx, cfg)
+		return llmodel.NewClaudeModel(ctx, cfg)
 	case config.ProviderDeepseek:
-		chatModel, err = llmodel.NewDeepseekModel(ctx, cfg)
+		return llmodel.NewDeepseekModel(ctx, cfg)
 	case config.ProviderOllama:
-		chatModel, err = llmodel.NewOllamaModel(ctx, cfg)
+		return llmodel.NewOllamaModel(ctx, cfg)
 	default:
-		return fmt.Errorf("unsupported provider: %s", cfg.DefaultProvider)
+		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
-
-	llmAgent, err := agent.NewAgent(ctx, chatModel)
-	if err := presetation.StartApp(llmAgent, query); err != nil {
-		return err
-	}
-
-	return nil
 }
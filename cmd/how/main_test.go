@@ -0,0 +1,64 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRunsSubcommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{args: []string{"search", "ffmpeg"}, want: true},
+		{args: []string{"fix"}, want: true},
+		{args: []string{"suggest", "--raw", "list", "files"}, want: true},
+		{args: []string{"init"}, want: true},
+		{args: []string{"init", "--local"}, want: true},
+		{args: []string{"init", "a", "git", "repository"}, want: false},
+		{args: []string{"audit"}, want: true},
+		{args: []string{"audit", "--since", "1d"}, want: true},
+		{args: []string{"audit", "my", "ssh", "config"}, want: false},
+		{args: []string{"usage", "--by", "day"}, want: true},
+		{args: []string{"usage", "of", "tar"}, want: false},
+		{args: []string{"completion", "zsh"}, want: true},
+		{args: []string{"completion", "of", "git", "commands"}, want: false},
+		{args: []string{"shell-init", "bash"}, want: true},
+		{args: []string{"shell-init", "powershell"}, want: false},
+		{args: []string{"policy"}, want: true},
+		{args: []string{"policy", "test", "rm", "-rf", "/"}, want: true},
+		{args: []string{"policy", "for", "sudo"}, want: false},
+		{args: []string{"list", "large", "files"}, want: false},
+		{args: []string{"--", "search", "for", "large", "files"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := runsSubcommand(tt.args); got != tt.want {
+			t.Errorf("runsSubcommand(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExtractFlag(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		value string
+		rest  []string
+		ok    bool
+	}{
+		{name: "separate value", args: []string{"--model", "gpt", "list", "files"}, value: "gpt", rest: []string{"list", "files"}, ok: true},
+		{name: "joined value", args: []string{"list", "--model=gpt"}, value: "gpt", rest: []string{"list"}, ok: true},
+		{name: "missing value", args: []string{"list", "--model"}, rest: []string{"list", "--model"}},
+		{name: "after separator", args: []string{"--", "what", "is", "--model", "gpt"}, rest: []string{"--", "what", "is", "--model", "gpt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, rest, ok := extractFlag(tt.args, "--model")
+			if value != tt.value || !slices.Equal(rest, tt.rest) || ok != tt.ok {
+				t.Errorf("extractFlag(%q) = %q, %q, %v, want %q, %q, %v", tt.args, value, rest, ok, tt.value, tt.rest, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antunesgabriel/how/infrastructure/command"
+)
+
+// handlePolicy runs the policy subcommands. Usage: how policy test "<cmd>"
+func handlePolicy(args []string) error {
+	if len(args) < 2 || args[0] != "test" {
+		return errors.New(`usage: how policy test "<command>"`)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	policy, err := command.NewPolicy(cfg.ActivePolicy())
+	if err != nil {
+		return err
+	}
+
+	line := strings.Join(args[1:], " ")
+	decision := policy.Evaluate(line)
+
+	if cfg.Profile != "" {
+		fmt.Printf("Profile: %s\n", cfg.Profile)
+	}
+	fmt.Printf("Command: %s\n", line)
+	fmt.Printf("Decision: %s\n", decision.Action)
+
+	if decision.Command != "" && decision.Command != line {
+		fmt.Printf("Matched command: %s\n", decision.Command)
+	}
+	if decision.Rule != "" {
+		fmt.Printf("Rule: %s\n", decision.Rule)
+	}
+	if decision.Reason != "" {
+		fmt.Printf("Reason: %s\n", decision.Reason)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/antunesgabriel/how/infrastructure/audit"
+	"github.com/antunesgabriel/how/infrastructure/search"
+	"github.com/antunesgabriel/how/infrastructure/session"
+)
+
+// handleSearch prints the messages, titles and commands of past sessions matching the terms, best first.
+// Usage: how search [flags] <terms>
+func handleSearch(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	limit := fs.Int("limit", 10, "maximum number of results to show, 0 for no limit")
+	asJSON := fs.Bool("json", false, "print the results as JSON Lines")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	query := strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("usage: how search [--limit N] [--json] <terms>")
+	}
+
+	results, err := search.NewSearcher(session.DefaultDirPath(), audit.DefaultPath()).Search(query, *limit)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, result := range results {
+			if err := encoder.Encode(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No matches found in the sessions.")
+		return nil
+	}
+
+	for _, result := range results {
+		sessionID := result.SessionID
+		if len(sessionID) > 8 {
+			sessionID = sessionID[:8]
+		}
+
+		title := result.Title
+		if title == "" {
+			title = "(session not saved)"
+		}
+
+		fmt.Printf("%s  %s  %s\n", result.Time.Local().Format("2006-01-02 15:04"), sessionID, title)
+		fmt.Printf("  %s: %s\n", result.Role, result.Snippet)
+
+		switch {
+		case result.Message >= 0:
+			fmt.Printf("  how --resume %s:%d\n", sessionID, result.Message)
+		case result.Role != "command":
+			fmt.Printf("  how --resume %s\n", sessionID)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antunesgabriel/how/infrastructure/shell"
+)
+
+// handleShellInit prints the integration script of a shell. Usage: how shell-init bash|zsh|fish
+func handleShellInit(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: how shell-init %s", strings.Join(shell.Supported, "|"))
+	}
+
+	script, err := shell.InitScript(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(script)
+	return nil
+}
+
+// handleFix asks the agent to explain and fix the last command recorded by the shell integration.
+// Any extra argument is sent to the agent as additional context. Usage: how fix [context]
+func handleFix(ctx context.Context, args []string) error {
+	last, err := shell.ReadLastCommand(shell.StateDirPath())
+	if err != nil {
+		if errors.Is(err, shell.ErrNoLastCommand) {
+			fmt.Println("No command recorded yet.")
+			fmt.Println(`Enable the shell integration first, for example: eval "$(how shell-init bash)"`)
+			return fmt.Errorf("shell integration required")
+		}
+		return err
+	}
+
+	if last.ExitCode == 0 && len(args) == 0 {
+		fmt.Printf("The last command succeeded, nothing to fix: %s\n", last.Command)
+		return nil
+	}
+
+	modeName := mode
+	if modeName == "" {
+		modeName = "debug"
+	}
+
+	return startApp(ctx, fixPrompt(last, strings.Join(args, " ")), modeName)
+}
+
+func fixPrompt(last *shell.LastCommand, extra string) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "I ran this command and it exited with code %d", last.ExitCode)
+	if last.Cwd != "" {
+		fmt.Fprintf(&sb, " in the directory %s", last.Cwd)
+	}
+	fmt.Fprintf(&sb, ":\n\n```sh\n%s\n```\n\n", last.Command)
+
+	if last.Stderr != "" {
+		fmt.Fprintf(&sb, "The error output was:\n\n```\n%s\n```\n\n", last.Stderr)
+	}
+
+	if extra != "" {
+		fmt.Fprintf(&sb, "Additional context: %s\n\n", extra)
+	}
+
+	sb.WriteString("Explain why it failed and propose a corrected command.")
+	return sb.String()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+	"github.com/antunesgabriel/how/infrastructure/orchestration/agent"
+	"github.com/antunesgabriel/how/infrastructure/prompt"
+)
+
+// handleSuggest prints the command suggested for a natural language request without starting the TUI.
+// With --raw only the command is printed, so shell widgets can replace the current buffer with it.
+// Usage: how suggest [--raw] [--] <request>
+func handleSuggest(ctx context.Context, args []string) error {
+	raw, request, err := parseSuggestArgs(args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	chatModel, err := newChatModel(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	prompts, err := prompt.NewLibrary(cfg.Prompts, config.PromptsDirPath())
+	if err != nil {
+		return err
+	}
+
+	modeName := mode
+	if modeName == "" {
+		modeName = "generate"
+	}
+
+	agentMode, _, err := resolveMode(cfg, prompts, modeName)
+	if err != nil {
+		return err
+	}
+
+	llmAgent, err := agent.NewAgent(ctx, chatModel, agentMode, agent.WithEnvironment(newEnvironment(cfg)))
+	if err != nil {
+		return err
+	}
+
+	answer, err := llmAgent.GetResponse(ctx, []domain.Message{{Role: domain.RoleUser, Content: request}})
+	if err != nil {
+		return err
+	}
+
+	if !raw {
+		fmt.Println(strings.TrimSpace(answer.Content))
+		return nil
+	}
+
+	command, err := agent.ExtractCommand(answer.Content)
+	if err != nil {
+		return fmt.Errorf("the suggested script cannot be used as one command: %w", err)
+	}
+	if command == "" {
+		return errors.New("no command found in the answer")
+	}
+
+	fmt.Println(command)
+	return nil
+}
+
+// parseSuggestArgs reads the flags before the request, so the request itself can contain "--raw" or "--"
+func parseSuggestArgs(args []string) (bool, string, error) {
+	raw := false
+
+	words := args
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		flag := words[0]
+		words = words[1:]
+
+		if flag == "--" {
+			break
+		}
+		if flag != "--raw" {
+			return false, "", fmt.Errorf("unknown flag %s, usage: how suggest [--raw] [--] <request>", flag)
+		}
+
+		raw = true
+	}
+
+	request := strings.TrimSpace(strings.Join(words, " "))
+	if request == "" {
+		return false, "", errors.New("usage: how suggest [--raw] [--] <request>")
+	}
+
+	return raw, request, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestParseSuggestArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		raw     bool
+		request string
+		err     bool
+	}{
+		{name: "request", args: []string{"list", "files"}, request: "list files"},
+		{name: "raw", args: []string{"--raw", "list", "files"}, raw: true, request: "list files"},
+		{name: "raw and separator", args: []string{"--raw", "--", "-h", "means"}, raw: true, request: "-h means"},
+		{name: "flags in the request", args: []string{"explain", "--raw", "and", "--"}, request: "explain --raw and --"},
+		{name: "unknown flag", args: []string{"--json", "ls"}, err: true},
+		{name: "empty request", args: []string{"--raw", "--", " "}, err: true},
+		{name: "no arguments", args: nil, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, request, err := parseSuggestArgs(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseSuggestArgs(%q) = %v, %q, want an error", tt.args, raw, request)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseSuggestArgs(%q) returned an error: %v", tt.args, err)
+			}
+			if raw != tt.raw || request != tt.request {
+				t.Errorf("parseSuggestArgs(%q) = %v, %q, want %v, %q", tt.args, raw, request, tt.raw, tt.request)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/antunesgabriel/how/domain"
+	"github.com/antunesgabriel/how/infrastructure/session"
+)
+
+type usageTotal struct {
+	requests int
+	usage    domain.Usage
+}
+
+func (t *usageTotal) add(usage *domain.Usage) {
+	t.requests++
+	t.usage.PromptTokens += usage.PromptTokens
+	t.usage.CompletionTokens += usage.CompletionTokens
+	t.usage.TotalTokens += usage.TotalTokens
+	t.usage.Cost += usage.Cost
+}
+
+// handleUsage prints the tokens and cost of the answers stored in the sessions. Usage: how usage [flags]
+func handleUsage(args []string) error {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	since := fs.String("since", "", "only count answers received after this time. Exe: 24h, 7d, 2025-01-31")
+	by := fs.String("by", "model", "group the usage by model, day or session")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q, usage: how usage [flags]", fs.Arg(0))
+	}
+
+	var sinceTime time.Time
+	if *since != "" {
+		parsed, err := parseSince(*since, time.Now())
+		if err != nil {
+			return err
+		}
+		sinceTime = parsed
+	}
+
+	var keyOf func(s domain.Session, msg domain.Message) string
+	switch *by {
+	case "model":
+		keyOf = func(_ domain.Session, msg domain.Message) string {
+			if msg.Usage.Model == "" {
+				return msg.Usage.Provider
+			}
+			return msg.Usage.Provider + "/" + msg.Usage.Model
+		}
+	case "day":
+		keyOf = func(_ domain.Session, msg domain.Message) string {
+			return msg.CreatedAt.Local().Format("2006-01-02")
+		}
+	case "session":
+		keyOf = func(s domain.Session, _ domain.Message) string {
+			return s.ID
+		}
+	default:
+		return fmt.Errorf("invalid --by value %q, use model, day or session", *by)
+	}
+
+	sessions, err := session.NewStore(session.DefaultDirPath()).List()
+	if err != nil {
+		return err
+	}
+
+	totals := map[string]*usageTotal{}
+	overall := &usageTotal{}
+
+	// The answers of the branches left by /retry and /edit count too, and the titles written by the model
+	for _, s := range sessions {
+		titles := make([]domain.Message, len(s.TitleUsage))
+		for idx := range s.TitleUsage {
+			titles[idx] = domain.Message{CreatedAt: s.CreatedAt, Usage: &s.TitleUsage[idx]}
+		}
+
+		for _, msg := range slices.Concat(s.Messages, s.Branches, titles) {
+			if msg.Usage == nil || msg.CreatedAt.Before(sinceTime) {
+				continue
+			}
+
+			key := keyOf(s, msg)
+			if totals[key] == nil {
+				totals[key] = &usageTotal{}
+			}
+
+			totals[key].add(msg.Usage)
+			overall.add(msg.Usage)
+		}
+	}
+
+	if overall.requests == 0 {
+		fmt.Println("No usage recorded in the sessions.")
+		return nil
+	}
+
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tREQUESTS\tINPUT\tOUTPUT\tTOTAL\tCOST\n", strings.ToUpper(*by))
+	for _, key := range keys {
+		printUsageRow(w, key, totals[key])
+	}
+	printUsageRow(w, "TOTAL", overall)
+
+	return w.Flush()
+}
+
+func printUsageRow(w *tabwriter.Writer, key string, total *usageTotal) {
+	fmt.Fprintf(
+		w,
+		"%s\t%d\t%d\t%d\t%d\t$%.4f\n",
+		key,
+		total.requests,
+		total.usage.PromptTokens,
+		total.usage.CompletionTokens,
+		total.usage.TotalTokens,
+		total.usage.Cost,
+	)
+}
@@ -172,12 +172,28 @@ type OllamaChatModelConfig struct {
 }
 
 type Config struct {
-	DefaultProvider Provider                 `yaml:"default_provider"`
-	OpenAI          *OpenAIChatModelConfig   `yaml:"openai,omitempty"`
-	Gemini          *GeminiConfig            `yaml:"gemini,omitempty"`
-	Claude          *ClaudeConfig            `yaml:"claude,omitempty"`
-	Deepseek        *DeepseekChatModelConfig `yaml:"deepseek,omitempty"`
-	Ollama          *OllamaChatModelConfig   `yaml:"ollama,omitempty"`
+	DefaultProvider Provider                            `yaml:"default_provider"`
+	OpenAI          *OpenAIChatModelConfig              `yaml:"openai,omitempty"`
+	Gemini          *GeminiConfig                       `yaml:"gemini,omitempty"`
+	Claude          *ClaudeConfig                       `yaml:"claude,omitempty"`
+	Deepseek        *DeepseekChatModelConfig            `yaml:"deepseek,omitempty"`
+	Ollama          *OllamaChatModelConfig              `yaml:"ollama,omitempty"`
+	Environment     *EnvironmentConfig                  `yaml:"environment,omitempty"`
+	Context         *ContextConfig                      `yaml:"context,omitempty"`
+	Pricing         map[Provider]map[string]PriceConfig `yaml:"pricing,omitempty"`
+	Fallback        []Provider                          `yaml:"fallback,omitempty"`
+	Retry           *RetryConfig                        `yaml:"retry,omitempty"`
+	SystemPrompt    string                              `yaml:"system_prompt,omitempty"`
+	Prompts         map[string]string                   `yaml:"prompts,omitempty"`
+	Mode            string                              `yaml:"mode,omitempty"`
+	Modes           map[string]*ModeConfig              `yaml:"modes,omitempty"`
+	Profile         string                              `yaml:"profile,omitempty"`
+	Policy          *PolicyConfig                       `yaml:"policy,omitempty"`
+	Profiles        map[string]*ProfileConfig           `yaml:"profiles,omitempty"`
+	Theme           string                              `yaml:"theme,omitempty"`
+	Themes          map[string]*ThemeConfig             `yaml:"themes,omitempty"`
+	Keys            *KeysConfig                         `yaml:"keys,omitempty"`
+	Sessions        *SessionsConfig                     `yaml:"sessions,omitempty"`
 }
 
 // GlobalConfigFilePath returns the path to the global configuration file
@@ -291,6 +307,49 @@ func Load(provider, model string) (*Config, error) {
 	return &config, nil
 }
 
+// Read reads the configuration from the local or the global configuration file without validating it
+func Read() (*Config, error) {
+	path := LocalConfigFilePath()
+	if _, err := os.Stat(path); err != nil {
+		path = GlobalConfigFilePath()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %w", err)
+	}
+
+	return &config, nil
+}
+
+// ConfiguredProviders returns the providers that have a configuration section
+func (c *Config) ConfiguredProviders() []Provider {
+	var providers []Provider
+
+	if c.OpenAI != nil {
+		providers = append(providers, ProviderOpenAI)
+	}
+	if c.Gemini != nil {
+		providers = append(providers, ProviderGemini)
+	}
+	if c.Claude != nil {
+		providers = append(providers, ProviderClaude)
+	}
+	if c.Deepseek != nil {
+		providers = append(providers, ProviderDeepseek)
+	}
+	if c.Ollama != nil {
+		providers = append(providers, ProviderOllama)
+	}
+
+	return providers
+}
+
 func (c *Config) Validate(currentModel string) error {
 	if c.DefaultProvider == "" {
 		return errors.New("default_provider is required")
@@ -298,13 +357,14 @@ func (c *Config) Validate(currentModel string) error {
 
 	switch c.DefaultProvider {
 	case ProviderOpenAI:
+		if c.OpenAI == nil {
+			return errors.New("openai configuration is required when default_provider is openai")
+		}
+
 		if currentModel != "" {
 			c.OpenAI.Model = currentModel
 		}
 
-		if c.OpenAI == nil {
-			return errors.New("openai configuration is required when default_provider is openai")
-		}
 		if c.OpenAI.APIKey == "" {
 			return errors.New("openai.api_key is required")
 		}
@@ -312,13 +372,14 @@ func (c *Config) Validate(currentModel string) error {
 			return errors.New("openai.model is required")
 		}
 	case ProviderGemini:
+		if c.Gemini == nil {
+			return errors.New("gemini configuration is required when default_provider is gemini")
+		}
+
 		if currentModel != "" {
 			c.Gemini.Model = currentModel
 		}
 
-		if c.Gemini == nil {
-			return errors.New("gemini configuration is required when default_provider is gemini")
-		}
 		if c.Gemini.APIKey == "" {
 			return errors.New("gemini.api_key is required")
 		}
@@ -326,13 +387,14 @@ func (c *Config) Validate(currentModel string) error {
 			return errors.New("gemini.model is required")
 		}
 	case ProviderClaude:
+		if c.Claude == nil {
+			return errors.New("claude configuration is required when default_provider is claude")
+		}
+
 		if currentModel != "" {
 			c.Claude.Model = currentModel
 		}
 
-		if c.Claude == nil {
-			return errors.New("claude configuration is required when default_provider is claude")
-		}
 		if c.Claude.APIKey == "" {
 			return errors.New("claude.api_key is required")
 		}
@@ -340,15 +402,16 @@ func (c *Config) Validate(currentModel string) error {
 			return errors.New("claude.model is required")
 		}
 	case ProviderDeepseek:
-		if currentModel != "" {
-			c.Deepseek.Model = currentModel
-		}
-
 		if c.Deepseek == nil {
 			return errors.New(
 				"deepseek configuration is required when default_provider is deepseek",
 			)
 		}
+
+		if currentModel != "" {
+			c.Deepseek.Model = currentModel
+		}
+
 		if c.Deepseek.APIKey == "" {
 			return errors.New("deepseek.api_key is required")
 		}
@@ -356,13 +419,14 @@ func (c *Config) Validate(currentModel string) error {
 			return errors.New("deepseek.model is required")
 		}
 	case ProviderOllama:
+		if c.Ollama == nil {
+			return errors.New("ollama configuration is required when default_provider is ollama")
+		}
+
 		if currentModel != "" {
 			c.Ollama.Model = currentModel
 		}
 
-		if c.Ollama == nil {
-			return errors.New("ollama configuration is required when default_provider is ollama")
-		}
 		if c.Ollama.BaseURL == "" {
 			return errors.New("ollama.base_url is required")
 		}
@@ -373,6 +437,54 @@ func (c *Config) Validate(currentModel string) error {
 		return fmt.Errorf("unsupported provider: %s", c.DefaultProvider)
 	}
 
+	if err := validateFallback(c); err != nil {
+		return err
+	}
+
+	if c.Profile != "" {
+		if _, ok := c.Profiles[c.Profile]; !ok {
+			return fmt.Errorf("profile %q not found in profiles", c.Profile)
+		}
+	}
+
+	if err := validatePolicy("policy", c.Policy); err != nil {
+		return err
+	}
+
+	if c.Mode != "" {
+		if _, ok := BuiltinModes[c.Mode]; !ok && c.Modes[c.Mode] == nil {
+			return fmt.Errorf("mode %q not found in modes", c.Mode)
+		}
+	}
+
+	for name, mode := range c.Modes {
+		if err := validateMode(fmt.Sprintf("modes.%s", name), mode); err != nil {
+			return err
+		}
+	}
+
+	if err := validateThemes(c); err != nil {
+		return err
+	}
+
+	if err := validateKeys(c.Keys); err != nil {
+		return err
+	}
+
+	if err := validateSessions(c.Sessions); err != nil {
+		return err
+	}
+
+	for name, profile := range c.Profiles {
+		if profile == nil {
+			continue
+		}
+
+		if err := validatePolicy(fmt.Sprintf("profiles.%s.policy", name), profile.Policy); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
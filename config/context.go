@@ -0,0 +1,185 @@
+package config
+
+import (
+	"strings"
+)
+
+const (
+	// DefaultContextWindow is used for models missing from ModelContextWindows
+	DefaultContextWindow = 8192
+
+	// DefaultReservedTokens are left free in the context window for the answer of the model
+	DefaultReservedTokens = 4096
+
+	// DefaultToolOutputTokens limit each command output or tool result sent to the model
+	DefaultToolOutputTokens = 2000
+)
+
+// ModelContextWindows are the context windows, in tokens, of well known models by name prefix
+var ModelContextWindows = map[string]int{
+	"gpt-4o":           128000,
+	"gpt-4.1":          1000000,
+	"gpt-4-turbo":      128000,
+	"gpt-4":            8192,
+	"gpt-3.5-turbo":    16385,
+	"o1":               200000,
+	"o3":               200000,
+	"o4":               200000,
+	"claude":           200000,
+	"gemini-1.5":       1000000,
+	"gemini-2":         1000000,
+	"gemini-pro":       32760,
+	"deepseek":         64000,
+	"llama3":           8192,
+	"llama3.1":         128000,
+	"llama3.2":         128000,
+	"qwen2.5":          32768,
+	"mistral":          32768,
+	"anthropic.claude": 200000,
+}
+
+// ContextConfig controls how much of the conversation is sent to the model on each request
+type ContextConfig struct {
+	// MaxTokens is the budget for the messages sent on each request, including the system prompt.
+	// Tokens are estimated at about four characters each
+	// Optional. Default: the context window of the model minus ReservedTokens
+	MaxTokens int `yaml:"max_tokens,omitempty"`
+
+	// ReservedTokens are left free in the context window for the answer of the model
+	// Optional. Default: 4096
+	ReservedTokens int `yaml:"reserved_tokens,omitempty"`
+
+	// Models overrides the context window of models by name prefix
+	// Example: {"llama3": 32768}
+	// Optional
+	Models map[string]int `yaml:"models,omitempty"`
+
+	// ToolOutputTokens limits each command output or tool result, keeping its beginning and end
+	// Optional. Default: 2000
+	ToolOutputTokens int `yaml:"tool_output_tokens,omitempty"`
+
+	// Summarize asks the model to summarize the turns that no longer fit instead of dropping them
+	// Optional. Default: false
+	Summarize bool `yaml:"summarize,omitempty"`
+}
+
+// CurrentModel returns the model configured for the default provider
+func (c *Config) CurrentModel() string {
+	return c.ProviderModel(c.DefaultProvider)
+}
+
+// WithModel returns a copy of the configuration where the default provider uses another model
+func (c *Config) WithModel(model string) *Config {
+	copied := *c
+
+	switch c.DefaultProvider {
+	case ProviderOpenAI:
+		if c.OpenAI != nil {
+			provider := *c.OpenAI
+			provider.Model, copied.OpenAI = model, &provider
+		}
+	case ProviderGemini:
+		if c.Gemini != nil {
+			provider := *c.Gemini
+			provider.Model, copied.Gemini = model, &provider
+		}
+	case ProviderClaude:
+		if c.Claude != nil {
+			provider := *c.Claude
+			provider.Model, copied.Claude = model, &provider
+		}
+	case ProviderDeepseek:
+		if c.Deepseek != nil {
+			provider := *c.Deepseek
+			provider.Model, copied.Deepseek = model, &provider
+		}
+	case ProviderOllama:
+		if c.Ollama != nil {
+			provider := *c.Ollama
+			provider.Model, copied.Ollama = model, &provider
+		}
+	}
+
+	return &copied
+}
+
+// ProviderModel returns the model configured for a provider
+func (c *Config) ProviderModel(provider Provider) string {
+	switch provider {
+	case ProviderOpenAI:
+		if c.OpenAI != nil {
+			return c.OpenAI.Model
+		}
+	case ProviderGemini:
+		if c.Gemini != nil {
+			return c.Gemini.Model
+		}
+	case ProviderClaude:
+		if c.Claude != nil {
+			return c.Claude.Model
+		}
+	case ProviderDeepseek:
+		if c.Deepseek != nil {
+			return c.Deepseek.Model
+		}
+	case ProviderOllama:
+		if c.Ollama != nil {
+			return c.Ollama.Model
+		}
+	}
+
+	return ""
+}
+
+// ContextBudget returns the number of tokens the messages of a request may use with the current model
+func (c *Config) ContextBudget() int {
+	if c.Context != nil && c.Context.MaxTokens > 0 {
+		return c.Context.MaxTokens
+	}
+
+	reserved := DefaultReservedTokens
+	if c.Context != nil && c.Context.ReservedTokens > 0 {
+		reserved = c.Context.ReservedTokens
+	}
+
+	var overrides map[string]int
+	if c.Context != nil {
+		overrides = c.Context.Models
+	}
+
+	window := contextWindow(c.CurrentModel(), overrides)
+	if window <= reserved {
+		return window / 2
+	}
+
+	return window - reserved
+}
+
+// ToolOutputBudget returns the number of tokens each command output or tool result may use
+func (c *Config) ToolOutputBudget() int {
+	if c.Context != nil && c.Context.ToolOutputTokens > 0 {
+		return c.Context.ToolOutputTokens
+	}
+
+	return DefaultToolOutputTokens
+}
+
+// contextWindow returns the window of the longest prefix matching the model, preferring the overrides
+func contextWindow(model string, overrides map[string]int) int {
+	model = strings.ToLower(model)
+
+	for _, windows := range []map[string]int{overrides, ModelContextWindows} {
+		window, matched := 0, -1
+		for prefix, size := range windows {
+			if strings.HasPrefix(model, strings.ToLower(prefix)) && len(prefix) > matched {
+				window, matched = size, len(prefix)
+			}
+		}
+
+		if matched >= 0 {
+			return window
+		}
+	}
+
+	return DefaultContextWindow
+}
@@ -0,0 +1,21 @@
+package config
+
+// EnvironmentConfig controls the information about the machine added to the system prompt
+type EnvironmentConfig struct {
+	// Disabled stops adding the environment information to the system prompt
+	// Optional. Default: false
+	Disabled bool `yaml:"disabled,omitempty"`
+
+	// Git adds the branch and a summary of the changes when the working directory is a git repository
+	// Optional. Default: true
+	Git *bool `yaml:"git,omitempty"`
+
+	// Tools are the executables whose presence is reported to the model
+	// Optional. Default: common package managers, container tools and language runtimes
+	Tools []string `yaml:"tools,omitempty"`
+}
+
+// GitEnabled reports whether the git summary is part of the environment information
+func (e *EnvironmentConfig) GitEnabled() bool {
+	return e == nil || e.Git == nil || *e.Git
+}
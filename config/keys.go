@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	KeyPresetDefault = "default"
+	KeyPresetEmacs   = "emacs"
+	KeyPresetVim     = "vim"
+)
+
+// KeyActions are the chat actions keys can be bound to
+var KeyActions = []string{
+	"send", "newline", "cancel", "quit", "history_previous", "history_next", "history_search",
+	"scroll_up", "scroll_down", "sessions", "help", "session_up", "session_down", "session_search",
+	"session_rename", "session_delete", "confirm_delete",
+}
+
+// KeysConfig controls the key bindings of the chat
+type KeysConfig struct {
+	// Preset is the set of bindings the overrides apply to
+	// Values: default, emacs, vim
+	// Optional. Default: default
+	Preset string `yaml:"preset,omitempty"`
+
+	// Bindings replaces the keys of an action. Keys are named as "enter", "esc", "ctrl+s", "alt+enter" or "f1"
+	// Exe: {send: ["ctrl+s"], newline: ["enter"]}
+	// Optional
+	Bindings map[string][]string `yaml:"bindings,omitempty"`
+}
+
+func validateKeys(keys *KeysConfig) error {
+	if keys == nil {
+		return nil
+	}
+
+	if keys.Preset != "" && keys.Preset != KeyPresetDefault && keys.Preset != KeyPresetEmacs && keys.Preset != KeyPresetVim {
+		return fmt.Errorf("keys.preset must be one of default, emacs or vim")
+	}
+
+	for action, bound := range keys.Bindings {
+		if !slices.Contains(KeyActions, action) {
+			return fmt.Errorf("keys.bindings.%s is not an action, use one of %v", action, KeyActions)
+		}
+
+		if len(bound) == 0 {
+			return fmt.Errorf("keys.bindings.%s needs at least one key", action)
+		}
+	}
+
+	return nil
+}
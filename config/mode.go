@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	ToolWebSearch      = "web_search"
+	ToolExecuteCommand = "execute_command"
+)
+
+const (
+	OutputMarkdown = "markdown"
+	OutputCommand  = "command"
+	OutputPlain    = "plain"
+)
+
+// DefaultMode is the name of the mode used when none is selected, which explains shell commands
+const DefaultMode = "explain"
+
+// ModeConfig defines how the agent behaves for a kind of task
+type ModeConfig struct {
+	// Prompt is the name of the system prompt used by the mode
+	// Optional. Default: the system prompt selected by system_prompt
+	Prompt string `yaml:"prompt,omitempty"`
+
+	// Tools the model can call in the mode, an empty list disables tools
+	// Values: web_search, execute_command
+	// Optional. Default: web_search, execute_command
+	Tools []string `yaml:"tools,omitempty"`
+
+	// Output is the format the model is asked to answer in
+	// Values: markdown, command, plain
+	// Optional. Default: markdown
+	Output string `yaml:"output,omitempty"`
+}
+
+// BuiltinModes are available without configuration and can be overridden in the modes section
+var BuiltinModes = map[string]ModeConfig{
+	"explain": {},
+	"generate": {
+		Prompt: "generate",
+		Tools:  []string{ToolWebSearch},
+		Output: OutputCommand,
+	},
+	"debug": {
+		Prompt: "debug",
+		Tools:  []string{ToolExecuteCommand, ToolWebSearch},
+	},
+	"review": {
+		Prompt: "review",
+		Tools:  []string{ToolWebSearch},
+	},
+}
+
+// ModeNames returns the names of the built-in and configured modes sorted alphabetically
+func (c *Config) ModeNames() []string {
+	names := make([]string, 0, len(BuiltinModes)+len(c.Modes))
+	for name := range BuiltinModes {
+		names = append(names, name)
+	}
+	for name := range c.Modes {
+		if _, ok := BuiltinModes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// ResolveMode returns the named mode with its defaults applied.
+// An empty name selects the mode of the configuration, or the explain mode
+func (c *Config) ResolveMode(name string) (ModeConfig, error) {
+	if name == "" {
+		name = c.Mode
+	}
+	if name == "" {
+		name = DefaultMode
+	}
+
+	mode, ok := BuiltinModes[name]
+	if configured, found := c.Modes[name]; found && configured != nil {
+		mode, ok = *configured, true
+	}
+
+	if !ok {
+		return ModeConfig{}, fmt.Errorf("mode %q not found, available: %v", name, c.ModeNames())
+	}
+
+	if mode.Prompt == "" {
+		mode.Prompt = c.ActiveSystemPrompt()
+	}
+	if mode.Tools == nil {
+		mode.Tools = []string{ToolWebSearch, ToolExecuteCommand}
+	}
+	if mode.Output == "" {
+		mode.Output = OutputMarkdown
+	}
+
+	return mode, nil
+}
+
+func validateMode(name string, mode *ModeConfig) error {
+	if mode == nil {
+		return nil
+	}
+
+	for idx, tool := range mode.Tools {
+		if tool != ToolWebSearch && tool != ToolExecuteCommand {
+			return fmt.Errorf("%s.tools[%d] must be one of web_search or execute_command", name, idx)
+		}
+	}
+
+	if mode.Output != "" && mode.Output != OutputMarkdown && mode.Output != OutputCommand && mode.Output != OutputPlain {
+		return fmt.Errorf("%s.output must be one of markdown, command or plain", name)
+	}
+
+	return nil
+}
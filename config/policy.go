@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	PolicyActionAllow   = "allow"
+	PolicyActionDeny    = "deny"
+	PolicyActionConfirm = "confirm"
+)
+
+// PolicyRule matches the commands parsed from a shell line
+type PolicyRule struct {
+	// Action is applied to the commands matched by the rule
+	// Values: allow, deny, confirm
+	// Required
+	Action string `yaml:"action"`
+
+	// Match is a glob matched against the whole command, "*" matches any text
+	// Example: "rm -rf *"
+	// Required if Regex is empty
+	Match string `yaml:"match,omitempty"`
+
+	// Regex is a regular expression matched against the whole command
+	// Example: "^kubectl (delete|drain) "
+	// Required if Match is empty
+	Regex string `yaml:"regex,omitempty"`
+
+	// Reason is shown to the user when the rule denies a command or requires a confirmation
+	// Optional
+	Reason string `yaml:"reason,omitempty"`
+}
+
+// PolicyConfig contains the rules evaluated before a command is executed
+type PolicyConfig struct {
+	// Default is the action applied to commands no rule matches
+	// Values: allow, deny, confirm
+	// Optional. Default: confirm
+	Default string `yaml:"default,omitempty"`
+
+	// Rules are evaluated in order, the first rule matching a command wins
+	// Optional
+	Rules []PolicyRule `yaml:"rules,omitempty"`
+}
+
+// ProfileConfig contains the settings that override the global ones when the profile is selected
+type ProfileConfig struct {
+	// Policy rules are evaluated before the global policy rules
+	// Optional
+	Policy *PolicyConfig `yaml:"policy,omitempty"`
+
+	// SystemPrompt is the name of the system prompt used with the profile
+	// Optional. Default: the global system_prompt
+	SystemPrompt string `yaml:"system_prompt,omitempty"`
+}
+
+// UseProfile selects the profile whose settings override the global ones
+func (c *Config) UseProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found in configuration", name)
+	}
+
+	c.Profile = name
+	return nil
+}
+
+// ActivePolicy returns the policy of the selected profile merged with the global policy.
+// Profile rules come first and the profile default action takes precedence
+func (c *Config) ActivePolicy() *PolicyConfig {
+	policy := &PolicyConfig{Default: PolicyActionConfirm}
+
+	if c.Policy != nil && c.Policy.Default != "" {
+		policy.Default = c.Policy.Default
+	}
+
+	if profile, ok := c.Profiles[c.Profile]; ok && profile != nil && profile.Policy != nil {
+		policy.Rules = append(policy.Rules, profile.Policy.Rules...)
+		if profile.Policy.Default != "" {
+			policy.Default = profile.Policy.Default
+		}
+	}
+
+	if c.Policy != nil {
+		policy.Rules = append(policy.Rules, c.Policy.Rules...)
+	}
+
+	return policy
+}
+
+func validatePolicy(name string, policy *PolicyConfig) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.Default != "" && !isPolicyAction(policy.Default) {
+		return fmt.Errorf("%s.default must be one of allow, deny or confirm", name)
+	}
+
+	for idx, rule := range policy.Rules {
+		if !isPolicyAction(rule.Action) {
+			return fmt.Errorf("%s.rules[%d].action must be one of allow, deny or confirm", name, idx)
+		}
+
+		if rule.Match == "" && rule.Regex == "" {
+			return fmt.Errorf("%s.rules[%d] requires match or regex", name, idx)
+		}
+
+		if rule.Regex != "" {
+			if _, err := regexp.Compile(rule.Regex); err != nil {
+				return fmt.Errorf("%s.rules[%d].regex is invalid: %w", name, idx, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func isPolicyAction(action string) bool {
+	return action == PolicyActionAllow || action == PolicyActionDeny || action == PolicyActionConfirm
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"strings"
+)
+
+// PriceConfig is the price of a model in USD per million tokens
+type PriceConfig struct {
+	// Input is the price of the prompt tokens
+	// Required
+	Input float64 `yaml:"input"`
+
+	// Output is the price of the completion tokens
+	// Required
+	Output float64 `yaml:"output"`
+}
+
+// Cost returns the price in USD of a request with the given token counts
+func (p PriceConfig) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / 1_000_000
+}
+
+// Price returns the price of the model of a provider from the pricing table.
+// Models are matched exactly or by the longest prefix, so "gpt-4o" also prices "gpt-4o-2024-08-06"
+func (c *Config) Price(provider Provider, model string) (PriceConfig, bool) {
+	models, ok := c.Pricing[provider]
+	if !ok {
+		return PriceConfig{}, false
+	}
+
+	if price, ok := models[model]; ok {
+		return price, true
+	}
+
+	var (
+		price   PriceConfig
+		matched = -1
+	)
+	for prefix, candidate := range models {
+		if strings.HasPrefix(model, prefix) && len(prefix) > matched {
+			price, matched = candidate, len(prefix)
+		}
+	}
+
+	return price, matched >= 0
+}
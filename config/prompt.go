@@ -0,0 +1,30 @@
+package config
+
+import (
+	"path/filepath"
+)
+
+// DefaultSystemPrompt is the name of the built-in system prompt, which explains shell commands
+const DefaultSystemPrompt = "default"
+
+// PromptsDirPath returns the path to the directory with the system prompt templates
+func PromptsDirPath() string {
+	configDir := GlobalConfigDirPath()
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "prompts")
+}
+
+// ActiveSystemPrompt returns the name of the system prompt selected by the profile or the global configuration
+func (c *Config) ActiveSystemPrompt() string {
+	if profile, ok := c.Profiles[c.Profile]; ok && profile != nil && profile.SystemPrompt != "" {
+		return profile.SystemPrompt
+	}
+
+	if c.SystemPrompt != "" {
+		return c.SystemPrompt
+	}
+
+	return DefaultSystemPrompt
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// RetryConfig controls how requests that fail with a transient error are retried
+type RetryConfig struct {
+	// MaxAttempts is the number of attempts made with each provider, 1 disables retries
+	// Optional. Default: 3
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+
+	// InitialBackoff is the wait before the first retry in milliseconds, doubled on every retry
+	// Optional. Default: 1000 (1 second)
+	InitialBackoff int `yaml:"initial_backoff,omitempty"`
+
+	// MaxBackoff is the longest wait between two attempts in milliseconds
+	// Optional. Default: 10000 (10 seconds)
+	MaxBackoff int `yaml:"max_backoff,omitempty"`
+}
+
+// RetryPolicy returns the retry settings with their defaults applied
+func (c *Config) RetryPolicy() (attempts int, initialBackoff, maxBackoff time.Duration) {
+	attempts, initialBackoff, maxBackoff = 3, time.Second, 10*time.Second
+
+	if c.Retry == nil {
+		return attempts, initialBackoff, maxBackoff
+	}
+
+	if c.Retry.MaxAttempts > 0 {
+		attempts = c.Retry.MaxAttempts
+	}
+	if c.Retry.InitialBackoff > 0 {
+		initialBackoff = time.Duration(c.Retry.InitialBackoff) * time.Millisecond
+	}
+	if c.Retry.MaxBackoff > 0 {
+		maxBackoff = time.Duration(c.Retry.MaxBackoff) * time.Millisecond
+	}
+
+	return attempts, initialBackoff, maxBackoff
+}
+
+func validateFallback(c *Config) error {
+	configured := c.ConfiguredProviders()
+
+	for idx, provider := range c.Fallback {
+		if provider == c.DefaultProvider {
+			return fmt.Errorf("fallback[%d] is the default provider %s", idx, provider)
+		}
+
+		if !slices.Contains(configured, provider) {
+			return fmt.Errorf("fallback[%d]: %s configuration is required", idx, provider)
+		}
+
+		if c.ProviderModel(provider) == "" {
+			return fmt.Errorf("fallback[%d]: %s.model is required", idx, provider)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+)
+
+const (
+	SessionTitlesModel     = "model"
+	SessionTitlesHeuristic = "heuristic"
+	SessionTitlesOff       = "off"
+)
+
+// SessionsConfig controls the saved chat sessions
+type SessionsConfig struct {
+	// Titles is how a session gets its title and tags after the first answer: asking the model,
+	// which falls back to the heuristic when it fails, with a local heuristic only, or not at all
+	// Values: model, heuristic, off
+	// Optional. Default: model
+	Titles string `yaml:"titles,omitempty"`
+
+	// TitleModel is the model of the default provider the titles are written with, usually a cheaper one
+	// than the chat model. Example: gpt-4o-mini
+	// Optional. Default: the chat model, with its fallback providers
+	TitleModel string `yaml:"title_model,omitempty"`
+}
+
+// SessionTitles returns how sessions are titled
+func (c *Config) SessionTitles() string {
+	if c.Sessions == nil || c.Sessions.Titles == "" {
+		return SessionTitlesModel
+	}
+
+	return c.Sessions.Titles
+}
+
+// SessionTitleModel returns the model the titles are written with, empty to use the chat model
+func (c *Config) SessionTitleModel() string {
+	if c.Sessions == nil {
+		return ""
+	}
+
+	return c.Sessions.TitleModel
+}
+
+func validateSessions(sessions *SessionsConfig) error {
+	if sessions == nil {
+		return nil
+	}
+
+	switch sessions.Titles {
+	case "", SessionTitlesModel, SessionTitlesHeuristic, SessionTitlesOff:
+		return nil
+	}
+
+	return fmt.Errorf("sessions.titles must be one of model, heuristic or off")
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+const (
+	ThemeAuto         = "auto"
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeMonochrome   = "monochrome"
+)
+
+// BuiltinThemes are the themes available without configuration. Auto picks dark or light
+// following the background of the terminal
+var BuiltinThemes = []string{ThemeAuto, ThemeDark, ThemeLight, ThemeHighContrast, ThemeMonochrome}
+
+// ThemeConfig defines a theme by changing some colours of a built-in one.
+// Colours are hex values such as "#FF75B5" or ANSI colour numbers such as "205"
+type ThemeConfig struct {
+	// Base is the built-in theme the colours are taken from when not set
+	// Optional. Default: auto
+	Base string `yaml:"base,omitempty"`
+
+	// Colours of the interface elements
+	// Optional. Default: the colours of the base theme
+	Title             string `yaml:"title,omitempty"`
+	Info              string `yaml:"info,omitempty"`
+	Prompt            string `yaml:"prompt,omitempty"`
+	User              string `yaml:"user,omitempty"`
+	Assistant         string `yaml:"assistant,omitempty"`
+	Command           string `yaml:"command,omitempty"`
+	CommandBackground string `yaml:"command_background,omitempty"`
+	Error             string `yaml:"error,omitempty"`
+	Warning           string `yaml:"warning,omitempty"`
+	Status            string `yaml:"status,omitempty"`
+	Confirm           string `yaml:"confirm,omitempty"`
+	ConfirmBackground string `yaml:"confirm_background,omitempty"`
+
+	// Markdown is the glamour style the answers are rendered with, a standard style name
+	// (dark, light, notty, ascii, dracula, pink, tokyo-night) or the path of a JSON style file
+	// Optional. Default: the style of the base theme
+	Markdown string `yaml:"markdown,omitempty"`
+}
+
+// ResolveTheme returns the theme selected by the theme setting, with its base defaulted. When the
+// NO_COLOR environment variable is set the monochrome theme is used, whatever the configuration says
+func (c *Config) ResolveTheme() (ThemeConfig, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		return ThemeConfig{Base: ThemeMonochrome}, nil
+	}
+
+	name := c.Theme
+	if name == "" {
+		name = ThemeAuto
+	}
+
+	if theme := c.Themes[name]; theme != nil {
+		resolved := *theme
+		if resolved.Base == "" {
+			resolved.Base = ThemeAuto
+		}
+		return resolved, nil
+	}
+
+	if slices.Contains(BuiltinThemes, name) {
+		return ThemeConfig{Base: name}, nil
+	}
+
+	return ThemeConfig{}, fmt.Errorf("theme %q not found in themes", name)
+}
+
+func validateThemes(c *Config) error {
+	if c.Theme != "" && !slices.Contains(BuiltinThemes, c.Theme) && c.Themes[c.Theme] == nil {
+		return fmt.Errorf("theme %q not found in themes", c.Theme)
+	}
+
+	for name, theme := range c.Themes {
+		if theme != nil && theme.Base != "" && !slices.Contains(BuiltinThemes, theme.Base) {
+			return fmt.Errorf("themes.%s.base must be one of auto, dark, light, high-contrast or monochrome", name)
+		}
+	}
+
+	return nil
+}
@@ -6,9 +6,11 @@ import (
 
 type StreamResponse interface {
 	Content() (string, bool, error)
+	Usage() *Usage
 }
 
 type Agent interface {
-	GetResponse(ctx context.Context, messages []Message) (string, error)
+	GetResponse(ctx context.Context, messages []Message) (Message, error)
 	GetStreamResponse(ctx context.Context, messages []Message) (StreamResponse, error)
+	SetSystemPrompt(prompt string)
 }
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+)
+
+type AuditEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	User        string    `json:"user"`
+	Cwd         string    `json:"cwd"`
+	Command     string    `json:"command"`
+	ExitCode    int       `json:"exit_code"`
+	DurationMs  int64     `json:"duration_ms"`
+	SessionID   string    `json:"session_id"`
+	SuggestedBy string    `json:"suggested_by,omitempty"`
+}
+
+type AuditLogger interface {
+	Append(entry AuditEntry) error
+}
@@ -0,0 +1,5 @@
+package domain
+
+type Clipboard interface {
+	Copy(text string) error
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+)
+
+type CommandRequest struct {
+	Command string
+	Reason  string
+}
+
+type CommandResult struct {
+	Approved bool
+	ExitCode int
+	Output   string
+	Reason   string
+}
+
+type CommandRunner interface {
+	RunCommand(ctx context.Context, request CommandRequest) (CommandResult, error)
+}
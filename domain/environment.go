@@ -0,0 +1,5 @@
+package domain
+
+type Environment interface {
+	Describe() string
+}
@@ -0,0 +1,33 @@
+package domain
+
+type ErrorKind string
+
+const (
+	ErrorAuth           ErrorKind = "auth"
+	ErrorRateLimit      ErrorKind = "rate_limit"
+	ErrorUnavailable    ErrorKind = "unavailable"
+	ErrorTimeout        ErrorKind = "timeout"
+	ErrorContextTooLong ErrorKind = "context_too_long"
+	ErrorNetwork        ErrorKind = "network"
+	ErrorToolFailure    ErrorKind = "tool_failure"
+	ErrorUnknown        ErrorKind = "unknown"
+)
+
+type AgentError struct {
+	Kind     ErrorKind
+	Provider string
+	Hint     string
+	Err      error
+}
+
+func (e *AgentError) Error() string {
+	if e.Provider == "" {
+		return e.Err.Error()
+	}
+
+	return e.Provider + ": " + e.Err.Error()
+}
+
+func (e *AgentError) Unwrap() error {
+	return e.Err
+}
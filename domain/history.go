@@ -0,0 +1,6 @@
+package domain
+
+type PromptHistory interface {
+	Entries() ([]string, error)
+	Append(entry string) error
+}
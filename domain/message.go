@@ -1,12 +1,23 @@
 package domain
 
+import (
+	"time"
+)
+
 type Message struct {
-	Role    string
-	Content string
+	ID          string    `json:"id,omitempty"`
+	ParentID    string    `json:"parent_id,omitempty"`
+	Role        string    `json:"role"`
+	Content     string    `json:"content"`
+	CreatedAt   time.Time `json:"created_at"`
+	Usage       *Usage    `json:"usage,omitempty"`
+	Interrupted bool      `json:"interrupted,omitempty"`
 }
 
 const (
 	RoleUser      = "user"
 	RoleAssistant = "assistant"
 	RoleSystem    = "system"
+	RoleError     = "error"
+	RoleNotice    = "notice"
 )
@@ -0,0 +1,20 @@
+package domain
+
+type PolicyAction string
+
+const (
+	PolicyAllow   PolicyAction = "allow"
+	PolicyDeny    PolicyAction = "deny"
+	PolicyConfirm PolicyAction = "confirm"
+)
+
+type PolicyDecision struct {
+	Action  PolicyAction
+	Command string
+	Rule    string
+	Reason  string
+}
+
+type CommandPolicy interface {
+	Evaluate(command string) PolicyDecision
+}
@@ -0,0 +1,6 @@
+package domain
+
+type PromptLibrary interface {
+	Names() []string
+	Render(name string) (string, error)
+}
@@ -0,0 +1,48 @@
+package domain
+
+type RiskLevel int
+
+const (
+	RiskNone RiskLevel = iota
+	RiskLow
+	RiskMedium
+	RiskHigh
+)
+
+func (l RiskLevel) String() string {
+	switch l {
+	case RiskLow:
+		return "low"
+	case RiskMedium:
+		return "medium"
+	case RiskHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+type RiskCategory string
+
+const (
+	RiskDestructive  RiskCategory = "destructive"
+	RiskPrivileged   RiskCategory = "privileged"
+	RiskNetwork      RiskCategory = "network"
+	RiskIrreversible RiskCategory = "irreversible"
+)
+
+type RiskFinding struct {
+	Category RiskCategory
+	Level    RiskLevel
+	Command  string
+	Reason   string
+}
+
+type RiskReport struct {
+	Level    RiskLevel
+	Findings []RiskFinding
+}
+
+type CommandAnalyzer interface {
+	Analyze(command string) RiskReport
+}
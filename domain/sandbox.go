@@ -0,0 +1,26 @@
+package domain
+
+type FileChangeKind string
+
+const (
+	FileAdded    FileChangeKind = "added"
+	FileModified FileChangeKind = "modified"
+	FileDeleted  FileChangeKind = "deleted"
+)
+
+type FileChange struct {
+	Path string
+	Kind FileChangeKind
+}
+
+type DryRunResult struct {
+	Isolation string
+	Output    string
+	ExitCode  int
+	Changes   []FileChange
+	Diff      string
+}
+
+type Sandbox interface {
+	DryRun(command string) (DryRunResult, error)
+}
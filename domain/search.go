@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+)
+
+type SearchResult struct {
+	SessionID string `json:"session_id"`
+	Title     string `json:"title,omitempty"`
+	// Message is the index of the matching message in the session, -1 when the match is not a message
+	Message int       `json:"message"`
+	Role    string    `json:"role"`
+	Snippet string    `json:"snippet"`
+	Time    time.Time `json:"time"`
+	Score   float64   `json:"score"`
+}
+
+type Searcher interface {
+	Search(query string, limit int) ([]SearchResult, error)
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type Session struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Messages   []Message `json:"messages"`
+	Branches   []Message `json:"branches,omitempty"`
+	TitleUsage []Usage   `json:"title_usage,omitempty"`
+}
+
+type SessionStore interface {
+	Save(session Session) error
+	Load(id string) (Session, error)
+	List() ([]Session, error)
+	Delete(id string) error
+}
+
+type SessionSummary struct {
+	Title string
+	Tags  []string
+	Usage *Usage
+}
+
+type SessionTitler interface {
+	TitleSession(ctx context.Context, messages []Message) (SessionSummary, error)
+}
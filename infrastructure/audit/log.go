@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+)
+
+// Filter selects the entries returned by Query. Zero values match every entry
+type Filter struct {
+	Since     time.Time
+	SessionID string
+	Contains  string
+	Failed    bool
+	Limit     int
+}
+
+// Log is an append-only audit log stored as JSON Lines
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// DefaultPath returns the path of the audit log inside the global configuration directory
+func DefaultPath() string {
+	configDir := config.GlobalConfigDirPath()
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "audit.jsonl")
+}
+
+// Append writes the entry at the end of the log, creating the file if needed
+func (l *Log) Append(entry domain.AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("error creating audit log directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding audit entry: %w", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing audit log: %w", err)
+	}
+
+	return nil
+}
+
+// Query returns the entries matching the filter, oldest first.
+// When a limit is set only the most recent entries are returned
+func (l *Log) Query(filter Filter) ([]domain.AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error opening audit log: %w", err)
+	}
+	defer file.Close()
+
+	entries := make([]domain.AuditEntry, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var entry domain.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+
+		if !filter.Since.IsZero() && entry.Timestamp.Before(filter.Since) {
+			continue
+		}
+		if filter.SessionID != "" && !strings.HasPrefix(entry.SessionID, filter.SessionID) {
+			continue
+		}
+		if filter.Contains != "" && !strings.Contains(strings.ToLower(entry.Command), strings.ToLower(filter.Contains)) {
+			continue
+		}
+		if filter.Failed && entry.ExitCode == 0 {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit log: %w", err)
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+
+	return entries, nil
+}
+
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
@@ -0,0 +1,48 @@
+package clipboard
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// Clipboard copies text to the clipboard of the user. In SSH sessions the local clipboard is on
+// another machine, so the text is sent to the terminal with the OSC 52 escape sequence. Elsewhere
+// the system clipboard tools are used, falling back to OSC 52 when none is installed
+type Clipboard struct {
+	terminal io.Writer
+}
+
+func (c *Clipboard) Copy(text string) error {
+	if !isSSH() && !clipboard.Unsupported {
+		if err := clipboard.WriteAll(text); err == nil {
+			return nil
+		}
+	}
+
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+
+	if _, err := seq.WriteTo(c.terminal); err != nil {
+		return fmt.Errorf("error copying to the clipboard: %w", err)
+	}
+
+	return nil
+}
+
+func isSSH() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// NewClipboard creates a clipboard that writes the OSC 52 sequence to the terminal
+func NewClipboard(terminal io.Writer) *Clipboard {
+	return &Clipboard{terminal: terminal}
+}
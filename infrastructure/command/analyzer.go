@@ -0,0 +1,51 @@
+package command
+
+import (
+	"github.com/antunesgabriel/how/domain"
+)
+
+type Analyzer struct {
+	rules []Rule
+}
+
+// Analyze parses the command and classifies every simple command it runs with the analyzer rules.
+// Commands that cannot be parsed are reported as medium risk, since they cannot be inspected
+func (a *Analyzer) Analyze(line string) domain.RiskReport {
+	report := domain.RiskReport{Level: domain.RiskNone}
+
+	commands, err := Parse(line)
+	if err != nil {
+		report.Level = domain.RiskMedium
+		report.Findings = append(report.Findings, domain.RiskFinding{
+			Category: domain.RiskDestructive,
+			Level:    domain.RiskMedium,
+			Command:  line,
+			Reason:   "the command could not be parsed, review it carefully: " + err.Error(),
+		})
+		return report
+	}
+
+	for _, cmd := range commands {
+		for _, rule := range a.rules {
+			found := rule(cmd)
+			if found == nil {
+				continue
+			}
+
+			report.Findings = append(report.Findings, *found)
+			if found.Level > report.Level {
+				report.Level = found.Level
+			}
+		}
+	}
+
+	return report
+}
+
+func NewAnalyzer(rules ...Rule) *Analyzer {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+
+	return &Analyzer{rules: rules}
+}
@@ -0,0 +1,377 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// SimpleCommand is a single command invocation found in a shell line,
+// after wrappers such as sudo or env have been unwrapped
+type SimpleCommand struct {
+	// Name is the base name of the executable. Example: "rm"
+	Name string
+
+	// Args are the arguments passed to the executable, unquoted when possible
+	Args []string
+
+	// Redirects are the output redirection targets of the command. Example: "/dev/sda"
+	Redirects []string
+
+	// PipedFrom is the name of the command whose output is piped into this one
+	PipedFrom string
+
+	// Wrappers are the privilege or environment wrappers the command was run through. Example: ["sudo"]
+	Wrappers []string
+
+	// Source is the command as written by the user
+	Source string
+}
+
+// String returns the command name followed by its arguments
+func (c SimpleCommand) String() string {
+	return strings.TrimSpace(c.Name + " " + strings.Join(c.Args, " "))
+}
+
+// HasFlag reports whether any of the given short or long flags was passed.
+// Short flags are also matched inside combined flags, so "r" matches "-rf"
+func (c SimpleCommand) HasFlag(flags ...string) bool {
+	for _, arg := range c.Args {
+		if arg == "--" {
+			return false
+		}
+
+		for _, flag := range flags {
+			if len(flag) > 1 {
+				if arg == "--"+flag || strings.HasPrefix(arg, "--"+flag+"=") {
+					return true
+				}
+				continue
+			}
+
+			if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg[1:], flag) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Operands returns the arguments that are not flags
+func (c SimpleCommand) Operands() []string {
+	operands := make([]string, 0, len(c.Args))
+	afterDashes := false
+
+	for _, arg := range c.Args {
+		if !afterDashes && arg == "--" {
+			afterDashes = true
+			continue
+		}
+
+		if !afterDashes && strings.HasPrefix(arg, "-") && arg != "-" {
+			continue
+		}
+
+		operands = append(operands, arg)
+	}
+
+	return operands
+}
+
+// wrappers are commands that run another command given as their arguments
+var wrappers = map[string]bool{
+	"sudo":    true,
+	"doas":    true,
+	"pkexec":  true,
+	"env":     true,
+	"nice":    true,
+	"nohup":   true,
+	"time":    true,
+	"command": true,
+	"exec":    true,
+	"xargs":   true,
+	"watch":   true,
+	"timeout": true,
+}
+
+// wrapperOptionsWithValue are the options of each wrapper that consume the next argument. Each wrapper has
+// its own, as the same flag may take a value for one and not for another, such as "-n" for xargs and sudo
+var wrapperOptionsWithValue = map[string]map[string]bool{
+	"sudo": {
+		"-u": true, "-g": true, "-C": true, "-D": true, "-p": true, "-h": true, "-U": true,
+		"--user": true, "--group": true, "--close-from": true, "--chdir": true, "--prompt": true, "--host": true, "--other-user": true,
+	},
+	"doas":    {"-u": true, "-C": true},
+	"pkexec":  {"--user": true},
+	"env":     {"-u": true, "-C": true, "--unset": true, "--chdir": true},
+	"nice":    {"-n": true, "--adjustment": true},
+	"xargs":   {"-I": true, "-L": true, "-n": true, "-P": true, "-d": true, "--max-lines": true, "--max-args": true, "--max-procs": true, "--delimiter": true},
+	"watch":   {"-n": true, "--interval": true},
+	"timeout": {"-s": true, "-k": true, "--signal": true, "--kill-after": true},
+}
+
+// shells are interpreters whose "-c" argument is itself a shell command
+var shells = map[string]bool{
+	"sh":   true,
+	"bash": true,
+	"zsh":  true,
+	"dash": true,
+	"ksh":  true,
+	"fish": true,
+}
+
+// Standalone reports whether the line is a single complete command, pipeline or list that can be chained
+// with others by "&&". Lines that open or close a compound command, heredocs and background jobs are not
+func Standalone(line string) bool {
+	parser := syntax.NewParser(syntax.Variant(syntax.LangBash))
+
+	file, err := parser.Parse(strings.NewReader(line), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return false
+	}
+
+	stmt := file.Stmts[0]
+	if stmt.Background || stmt.Coprocess || stmt.Semicolon.IsValid() {
+		return false
+	}
+
+	standalone := true
+	syntax.Walk(stmt, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.Redirect:
+			if node.Op == syntax.Hdoc || node.Op == syntax.DashHdoc {
+				standalone = false
+			}
+		case *syntax.FuncDecl:
+			standalone = false
+		}
+		return standalone
+	})
+
+	return standalone
+}
+
+// OneLine writes a script on a single line, with its statements separated by ";" and without its comments.
+// Heredocs cannot be written on a single line, so scripts with one are rejected
+func OneLine(script string) (string, error) {
+	parser := syntax.NewParser(syntax.Variant(syntax.LangBash))
+
+	file, err := parser.Parse(strings.NewReader(script), "")
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := syntax.NewPrinter(syntax.SingleLine(true)).Print(&buf, file); err != nil {
+		return "", err
+	}
+
+	line := strings.TrimSpace(buf.String())
+	if strings.Contains(line, "\n") {
+		return "", errors.New("the script has a heredoc, which cannot be written on a single line")
+	}
+
+	return line, nil
+}
+
+// Parse parses a shell command line and returns every simple command it runs,
+// including the ones inside pipelines, subshells, command substitutions and "sh -c" strings
+func Parse(line string) ([]SimpleCommand, error) {
+	parser := syntax.NewParser(syntax.Variant(syntax.LangBash))
+
+	file, err := parser.Parse(strings.NewReader(line), "")
+	if err != nil {
+		return nil, err
+	}
+
+	commands := make([]SimpleCommand, 0)
+	pipedFrom := map[*syntax.Stmt]string{}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.BinaryCmd:
+			if node.Op == syntax.Pipe || node.Op == syntax.PipeAll {
+				if name := lastCommandName(node.X); name != "" {
+					pipedFrom[firstStmt(node.Y)] = name
+				}
+			}
+		case *syntax.Stmt:
+			call, ok := node.Cmd.(*syntax.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+
+			cmd := SimpleCommand{
+				Source:    printNode(node),
+				PipedFrom: pipedFrom[node],
+			}
+
+			for _, redirect := range node.Redirs {
+				switch redirect.Op {
+				case syntax.RdrOut, syntax.AppOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll, syntax.RdrInOut:
+					if redirect.Word != nil {
+						cmd.Redirects = append(cmd.Redirects, wordString(redirect.Word))
+					}
+				}
+			}
+
+			args := make([]string, len(call.Args))
+			for idx, word := range call.Args {
+				args[idx] = wordString(word)
+			}
+
+			cmd.Wrappers, args = unwrap(args)
+			if len(args) == 0 {
+				return true
+			}
+
+			cmd.Name = filepath.Base(args[0])
+			cmd.Args = args[1:]
+			commands = append(commands, cmd)
+
+			if shells[cmd.Name] {
+				commands = append(commands, parseInlineScript(cmd)...)
+			}
+		}
+
+		return true
+	})
+
+	return commands, nil
+}
+
+// parseInlineScript parses the script passed to a shell through "-c"
+func parseInlineScript(cmd SimpleCommand) []SimpleCommand {
+	for idx, arg := range cmd.Args {
+		if arg != "-c" || idx+1 >= len(cmd.Args) {
+			continue
+		}
+
+		inner, err := Parse(cmd.Args[idx+1])
+		if err != nil {
+			return nil
+		}
+
+		for i := range inner {
+			inner[i].Wrappers = append(append([]string{}, cmd.Wrappers...), inner[i].Wrappers...)
+		}
+
+		return inner
+	}
+
+	return nil
+}
+
+// unwrap strips wrapper commands such as sudo or env and returns them apart from the wrapped command
+func unwrap(args []string) ([]string, []string) {
+	var found []string
+
+	for len(args) > 0 {
+		name := filepath.Base(args[0])
+		if !wrappers[name] {
+			break
+		}
+
+		found = append(found, name)
+		args = args[1:]
+
+		for len(args) > 0 {
+			arg := args[0]
+
+			if name == "env" && strings.Contains(arg, "=") && !strings.HasPrefix(arg, "-") {
+				args = args[1:]
+				continue
+			}
+
+			if name == "timeout" && len(arg) > 0 && arg[0] >= '0' && arg[0] <= '9' {
+				args = args[1:]
+				continue
+			}
+
+			if !strings.HasPrefix(arg, "-") {
+				break
+			}
+
+			args = args[1:]
+			if arg == "--" {
+				break
+			}
+
+			if wrapperOptionsWithValue[name][arg] && len(args) > 0 {
+				args = args[1:]
+			}
+		}
+	}
+
+	return found, args
+}
+
+// firstStmt returns the left-most statement of a pipeline side
+func firstStmt(stmt *syntax.Stmt) *syntax.Stmt {
+	if binary, ok := stmt.Cmd.(*syntax.BinaryCmd); ok {
+		return firstStmt(binary.X)
+	}
+
+	return stmt
+}
+
+// lastCommandName returns the name of the right-most command of a pipeline side
+func lastCommandName(stmt *syntax.Stmt) string {
+	switch cmd := stmt.Cmd.(type) {
+	case *syntax.BinaryCmd:
+		return lastCommandName(cmd.Y)
+	case *syntax.CallExpr:
+		args := make([]string, len(cmd.Args))
+		for idx, word := range cmd.Args {
+			args[idx] = wordString(word)
+		}
+
+		_, args = unwrap(args)
+		if len(args) == 0 {
+			return ""
+		}
+
+		return filepath.Base(args[0])
+	}
+
+	return ""
+}
+
+// wordString returns the unquoted value of a word, keeping expansions as written
+func wordString(word *syntax.Word) string {
+	var sb strings.Builder
+
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				if lit, ok := inner.(*syntax.Lit); ok {
+					sb.WriteString(lit.Value)
+					continue
+				}
+				sb.WriteString(printNode(inner))
+			}
+		default:
+			sb.WriteString(printNode(part))
+		}
+	}
+
+	return sb.String()
+}
+
+func printNode(node syntax.Node) string {
+	var buf bytes.Buffer
+	if err := syntax.NewPrinter().Print(&buf, node); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,216 @@
+package command
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseUnwrapsWrappers(t *testing.T) {
+	tests := []struct {
+		line     string
+		name     string
+		args     []string
+		wrappers []string
+	}{
+		{line: "rm -rf /tmp/x", name: "rm", args: []string{"-rf", "/tmp/x"}},
+		{line: "sudo rm -rf /", name: "rm", args: []string{"-rf", "/"}, wrappers: []string{"sudo"}},
+		{line: "sudo -u root rm x", name: "rm", args: []string{"x"}, wrappers: []string{"sudo"}},
+		{line: "sudo -- rm x", name: "rm", args: []string{"x"}, wrappers: []string{"sudo"}},
+		{line: "env FOO=1 BAR=2 make build", name: "make", args: []string{"build"}, wrappers: []string{"env"}},
+		{line: "timeout 5s curl example.com", name: "curl", args: []string{"example.com"}, wrappers: []string{"timeout"}},
+		{line: "timeout -s KILL -k 5 10 rm x", name: "rm", args: []string{"x"}, wrappers: []string{"timeout"}},
+		{line: "sudo -s rm x", name: "rm", args: []string{"x"}, wrappers: []string{"sudo"}},
+		{line: "sudo -n rm -rf /", name: "rm", args: []string{"-rf", "/"}, wrappers: []string{"sudo"}},
+		{line: "sudo -P rm -rf /", name: "rm", args: []string{"-rf", "/"}, wrappers: []string{"sudo"}},
+		{line: "sudo -h host rm x", name: "rm", args: []string{"x"}, wrappers: []string{"sudo"}},
+		{line: "nice -n 5 make", name: "make", wrappers: []string{"nice"}},
+		{line: "env -u HOME -i ls", name: "ls", wrappers: []string{"env"}},
+		{line: "xargs -n 1 -P 4 gzip", name: "gzip", wrappers: []string{"xargs"}},
+		{line: "watch -n 2 -d df -h", name: "df", args: []string{"-h"}, wrappers: []string{"watch"}},
+		{line: "sudo nice -n 10 nohup ./backup.sh", name: "backup.sh", wrappers: []string{"sudo", "nice", "nohup"}},
+		{line: "/usr/bin/sudo /bin/rm x", name: "rm", args: []string{"x"}, wrappers: []string{"sudo"}},
+		{line: "xargs -I {} rm {}", name: "rm", args: []string{"{}"}, wrappers: []string{"xargs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			commands, err := Parse(tt.line)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned an error: %v", tt.line, err)
+			}
+			if len(commands) != 1 {
+				t.Fatalf("Parse(%q) returned %d commands, want 1", tt.line, len(commands))
+			}
+
+			cmd := commands[0]
+			if cmd.Name != tt.name {
+				t.Errorf("Name = %q, want %q", cmd.Name, tt.name)
+			}
+			if len(cmd.Args) != len(tt.args) || (len(tt.args) > 0 && !slices.Equal(cmd.Args, tt.args)) {
+				t.Errorf("Args = %q, want %q", cmd.Args, tt.args)
+			}
+			if !slices.Equal(cmd.Wrappers, tt.wrappers) {
+				t.Errorf("Wrappers = %q, want %q", cmd.Wrappers, tt.wrappers)
+			}
+		})
+	}
+}
+
+func TestParseFindsNestedCommands(t *testing.T) {
+	tests := []struct {
+		line  string
+		names []string
+	}{
+		{line: "ls | grep go", names: []string{"ls", "grep"}},
+		{line: "make && make install || echo failed", names: []string{"make", "make", "echo"}},
+		{line: "(cd /tmp; rm x)", names: []string{"cd", "rm"}},
+		{line: "echo $(whoami)", names: []string{"echo", "whoami"}},
+		{line: "sh -c 'rm -rf / && echo done'", names: []string{"sh", "rm", "echo"}},
+		{line: "sudo bash -c \"rm x\"", names: []string{"bash", "rm"}},
+		{line: "FOO=1", names: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			commands, err := Parse(tt.line)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned an error: %v", tt.line, err)
+			}
+
+			var names []string
+			for _, cmd := range commands {
+				names = append(names, cmd.Name)
+			}
+			if !slices.Equal(names, tt.names) {
+				t.Errorf("names = %q, want %q", names, tt.names)
+			}
+		})
+	}
+}
+
+func TestParseInlineScriptKeepsWrappers(t *testing.T) {
+	commands, err := Parse("sudo sh -c 'rm x'")
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+	if len(commands) != 2 {
+		t.Fatalf("Parse returned %d commands, want 2", len(commands))
+	}
+
+	if inner := commands[1]; inner.Name != "rm" || !slices.Equal(inner.Wrappers, []string{"sudo"}) {
+		t.Errorf("inner command = %q wrapped by %q, want rm wrapped by sudo", inner.Name, inner.Wrappers)
+	}
+}
+
+func TestParsePipesAndRedirects(t *testing.T) {
+	commands, err := Parse("curl -fsSL https://example.com/install.sh | sudo bash > /dev/sda")
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+	if len(commands) != 2 {
+		t.Fatalf("Parse returned %d commands, want 2", len(commands))
+	}
+
+	if got := commands[1].PipedFrom; got != "curl" {
+		t.Errorf("PipedFrom = %q, want curl", got)
+	}
+	if got := commands[1].Redirects; !slices.Equal(got, []string{"/dev/sda"}) {
+		t.Errorf("Redirects = %q, want [/dev/sda]", got)
+	}
+}
+
+func TestSimpleCommandFlags(t *testing.T) {
+	tests := []struct {
+		line     string
+		flags    []string
+		want     bool
+		operands []string
+	}{
+		{line: "rm -rf dir", flags: []string{"r"}, want: true, operands: []string{"dir"}},
+		{line: "rm --recursive dir", flags: []string{"recursive"}, want: true, operands: []string{"dir"}},
+		{line: "rm -- -r", flags: []string{"r"}, want: false, operands: []string{"-r"}},
+		{line: "git push --force=true", flags: []string{"force"}, want: true, operands: []string{"push"}},
+		{line: "cat -", flags: []string{"n"}, want: false, operands: []string{"-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			commands, err := Parse(tt.line)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned an error: %v", tt.line, err)
+			}
+
+			cmd := commands[0]
+			if got := cmd.HasFlag(tt.flags...); got != tt.want {
+				t.Errorf("HasFlag(%q) = %v, want %v", tt.flags, got, tt.want)
+			}
+			if got := cmd.Operands(); !slices.Equal(got, tt.operands) {
+				t.Errorf("Operands() = %q, want %q", got, tt.operands)
+			}
+		})
+	}
+}
+
+func TestStandalone(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "ls -la", want: true},
+		{line: "make && make install", want: true},
+		{line: "ls | grep go", want: true},
+		{line: "if true; then echo yes; fi", want: true},
+		{line: "if [ -f x ]; then", want: false},
+		{line: "fi", want: false},
+		{line: "for f in *; do", want: false},
+		{line: "done", want: false},
+		{line: "cat <<EOF", want: false},
+		{line: "sleep 10 &", want: false},
+		{line: "echo a;", want: false},
+		{line: "echo a; echo b", want: false},
+		{line: "greet() { echo hi; }", want: false},
+		{line: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := Standalone(tt.line); got != tt.want {
+				t.Errorf("Standalone(%q) = %v, want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOneLine(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+		err    bool
+	}{
+		{script: "ls -la", want: "ls -la"},
+		{script: "cd /tmp\nls", want: "cd /tmp; ls"},
+		{script: "if true; then\n  echo yes # say it\nfi", want: "if true; then echo yes; fi"},
+		{script: "case $x in\n  a) echo a ;;\n  *) echo b ;;\nesac", want: "case $x in a) echo a ;; *) echo b ;; esac"},
+		{script: "cat <<EOF\nhello\nEOF", err: true},
+		{script: "for f in *; do", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			got, err := OneLine(tt.script)
+			if tt.err {
+				if err == nil {
+					t.Errorf("OneLine(%q) = %q, want an error", tt.script, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("OneLine(%q) returned an error: %v", tt.script, err)
+			}
+			if got != tt.want {
+				t.Errorf("OneLine(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
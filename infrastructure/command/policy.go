@@ -0,0 +1,181 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+)
+
+type policyRule struct {
+	action  domain.PolicyAction
+	pattern *regexp.Regexp
+	source  string
+	reason  string
+}
+
+type Policy struct {
+	rules         []policyRule
+	defaultAction domain.PolicyAction
+}
+
+// actionPriority orders the actions so the most restrictive decision wins over a whole shell line
+var actionPriority = map[domain.PolicyAction]int{
+	domain.PolicyAllow:   0,
+	domain.PolicyConfirm: 1,
+	domain.PolicyDeny:    2,
+}
+
+// Evaluate parses the command line and applies the first matching rule to every simple command.
+// The most restrictive decision found is returned, so a line is only allowed if all its commands are
+func (p *Policy) Evaluate(line string) domain.PolicyDecision {
+	commands, err := Parse(line)
+	if err != nil {
+		// The line is still checked as written, so a deny rule is not skipped by a line that does not parse
+		if decision, ok := p.denyLine(line); ok {
+			return decision
+		}
+
+		// Its commands cannot be matched by the other rules, so it is confirmed, or denied by a deny default
+		action := domain.PolicyConfirm
+		if p.defaultAction == domain.PolicyDeny {
+			action = domain.PolicyDeny
+		}
+
+		return domain.PolicyDecision{
+			Action:  action,
+			Command: line,
+			Reason:  "the command could not be parsed: " + err.Error(),
+		}
+	}
+
+	if len(commands) == 0 {
+		return domain.PolicyDecision{Action: p.defaultAction, Command: line}
+	}
+
+	var decision *domain.PolicyDecision
+
+	for _, cmd := range commands {
+		current := p.evaluateCommand(cmd)
+		if decision == nil || actionPriority[current.Action] > actionPriority[decision.Action] {
+			decision = &current
+		}
+	}
+
+	return *decision
+}
+
+// evaluateCommand applies the first matching rule to the command. Deny and confirm rules match the command
+// with or without its wrappers, but allow rules only match it as run, so "cat *" does not allow "sudo cat"
+func (p *Policy) evaluateCommand(cmd SimpleCommand) domain.PolicyDecision {
+	wrapped := cmd.String()
+	if len(cmd.Wrappers) > 0 {
+		wrapped = strings.Join(cmd.Wrappers, " ") + " " + cmd.String()
+	}
+
+	for _, rule := range p.rules {
+		candidates := []string{cmd.String(), wrapped}
+		if rule.action == domain.PolicyAllow {
+			candidates = []string{wrapped}
+		}
+
+		for _, candidate := range candidates {
+			if !rule.pattern.MatchString(candidate) {
+				continue
+			}
+
+			return domain.PolicyDecision{
+				Action:  rule.action,
+				Command: cmd.String(),
+				Rule:    rule.source,
+				Reason:  rule.reason,
+			}
+		}
+	}
+
+	return domain.PolicyDecision{
+		Action:  p.defaultAction,
+		Command: cmd.String(),
+		Reason:  "no rule matched, default action applied",
+	}
+}
+
+// denyLine applies the deny rules to the whole line as written
+func (p *Policy) denyLine(line string) (domain.PolicyDecision, bool) {
+	line = strings.TrimSpace(line)
+
+	for _, rule := range p.rules {
+		if rule.action == domain.PolicyDeny && rule.pattern.MatchString(line) {
+			return domain.PolicyDecision{
+				Action:  rule.action,
+				Command: line,
+				Rule:    rule.source,
+				Reason:  rule.reason,
+			}, true
+		}
+	}
+
+	return domain.PolicyDecision{}, false
+}
+
+// globToRegexp converts a glob where "*" matches any text and "?" any character into an anchored regexp
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+
+	sb.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+
+	return regexp.Compile(sb.String())
+}
+
+func NewPolicy(cfg *config.PolicyConfig) (*Policy, error) {
+	policy := &Policy{defaultAction: domain.PolicyConfirm}
+	if cfg == nil {
+		return policy, nil
+	}
+
+	if cfg.Default != "" {
+		policy.defaultAction = domain.PolicyAction(cfg.Default)
+	}
+
+	for idx, rule := range cfg.Rules {
+		var (
+			pattern *regexp.Regexp
+			source  string
+			err     error
+		)
+
+		if rule.Regex != "" {
+			pattern, err = regexp.Compile(rule.Regex)
+			source = "regex: " + rule.Regex
+		} else {
+			pattern, err = globToRegexp(rule.Match)
+			source = "match: " + rule.Match
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy rule %d: %w", idx, err)
+		}
+
+		policy.rules = append(policy.rules, policyRule{
+			action:  domain.PolicyAction(rule.Action),
+			pattern: pattern,
+			source:  source,
+			reason:  rule.Reason,
+		})
+	}
+
+	return policy, nil
+}
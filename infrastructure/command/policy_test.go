@@ -0,0 +1,131 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	cfg := &config.PolicyConfig{
+		Default: "confirm",
+		Rules: []config.PolicyRule{
+			{Action: "deny", Match: "rm -rf /*"},
+			{Action: "deny", Regex: "^kubectl (delete|drain) "},
+			{Action: "confirm", Match: "git push *"},
+			{Action: "allow", Match: "git *"},
+			{Action: "allow", Match: "ls*"},
+			{Action: "allow", Match: "cat *"},
+			{Action: "allow", Match: "sudo apt update"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want domain.PolicyAction
+		rule string
+	}{
+		{name: "allow", line: "ls -la", want: domain.PolicyAllow, rule: "match: ls*"},
+		{name: "first matching rule wins", line: "git push origin main", want: domain.PolicyConfirm, rule: "match: git push *"},
+		{name: "later allow rule", line: "git status", want: domain.PolicyAllow, rule: "match: git *"},
+		{name: "deny glob", line: "rm -rf /home", want: domain.PolicyDeny, rule: "match: rm -rf /*"},
+		{name: "deny regex", line: "kubectl delete pod x", want: domain.PolicyDeny, rule: "regex: ^kubectl (delete|drain) "},
+		{name: "default action", line: "make build", want: domain.PolicyConfirm},
+		{name: "deny wins over allow in a line", line: "ls && rm -rf /", want: domain.PolicyDeny, rule: "match: rm -rf /*"},
+		{name: "confirm wins over allow in a line", line: "ls | make", want: domain.PolicyConfirm},
+		{name: "all commands allowed", line: "ls | cat -n", want: domain.PolicyAllow},
+		{name: "deny matches unwrapped command", line: "sudo rm -rf /", want: domain.PolicyDeny, rule: "match: rm -rf /*"},
+		{name: "deny matches inside sh -c", line: "bash -c 'rm -rf /'", want: domain.PolicyDeny, rule: "match: rm -rf /*"},
+		{name: "deny matches inside substitution", line: "echo $(rm -rf /)", want: domain.PolicyDeny, rule: "match: rm -rf /*"},
+		{name: "allow does not match wrapped command", line: "sudo cat /etc/shadow", want: domain.PolicyConfirm},
+		{name: "allow matches wrapped command as run", line: "sudo apt update", want: domain.PolicyAllow, rule: "match: sudo apt update"},
+		{name: "allow does not match unwrapped command", line: "apt update", want: domain.PolicyConfirm},
+		{name: "deny applies to lines that do not parse", line: "rm -rf / 'unterminated", want: domain.PolicyDeny, rule: "match: rm -rf /*"},
+		{name: "lines that do not parse are confirmed", line: "ls 'unterminated", want: domain.PolicyConfirm},
+		{name: "assignment only", line: "FOO=1", want: domain.PolicyConfirm},
+	}
+
+	policy, err := NewPolicy(cfg)
+	if err != nil {
+		t.Fatalf("NewPolicy returned an error: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Evaluate(tt.line)
+			if decision.Action != tt.want {
+				t.Errorf("Evaluate(%q).Action = %s, want %s (%+v)", tt.line, decision.Action, tt.want, decision)
+			}
+			if tt.rule != "" && decision.Rule != tt.rule {
+				t.Errorf("Evaluate(%q).Rule = %q, want %q", tt.line, decision.Rule, tt.rule)
+			}
+		})
+	}
+}
+
+func TestPolicyDefaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        *config.PolicyConfig
+		want       domain.PolicyAction
+		unparsable domain.PolicyAction
+	}{
+		{name: "no policy", cfg: nil, want: domain.PolicyConfirm, unparsable: domain.PolicyConfirm},
+		{name: "empty default", cfg: &config.PolicyConfig{}, want: domain.PolicyConfirm, unparsable: domain.PolicyConfirm},
+		{name: "allow by default", cfg: &config.PolicyConfig{Default: "allow"}, want: domain.PolicyAllow, unparsable: domain.PolicyConfirm},
+		{name: "deny by default", cfg: &config.PolicyConfig{Default: "deny"}, want: domain.PolicyDeny, unparsable: domain.PolicyDeny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewPolicy returned an error: %v", err)
+			}
+
+			if got := policy.Evaluate("make build").Action; got != tt.want {
+				t.Errorf("Evaluate().Action = %s, want %s", got, tt.want)
+			}
+			if got := policy.Evaluate("echo 'unterminated").Action; got != tt.unparsable {
+				t.Errorf("Evaluate() of a line that does not parse = %s, want %s", got, tt.unparsable)
+			}
+		})
+	}
+}
+
+func TestNewPolicyInvalidRegex(t *testing.T) {
+	_, err := NewPolicy(&config.PolicyConfig{Rules: []config.PolicyRule{{Action: "deny", Regex: "("}}})
+	if err == nil {
+		t.Fatal("NewPolicy accepted an invalid regex")
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob  string
+		input string
+		want  bool
+	}{
+		{glob: "ls*", input: "ls -la", want: true},
+		{glob: "ls*", input: "als", want: false},
+		{glob: "rm ?", input: "rm x", want: true},
+		{glob: "rm ?", input: "rm xy", want: false},
+		{glob: "echo (a)+[b]", input: "echo (a)+[b]", want: true},
+		{glob: "echo (a)+[b]", input: "echo aab", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.input, func(t *testing.T) {
+			pattern, err := globToRegexp(tt.glob)
+			if err != nil {
+				t.Fatalf("globToRegexp(%q) returned an error: %v", tt.glob, err)
+			}
+
+			if got := pattern.MatchString(tt.input); got != tt.want {
+				t.Errorf("%q matches %q = %v, want %v", tt.glob, tt.input, got, tt.want)
+			}
+		})
+	}
+}
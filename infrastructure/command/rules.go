@@ -0,0 +1,292 @@
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+// Rule classifies a simple command. It returns nil when the command is not risky for this rule
+type Rule func(cmd SimpleCommand) *domain.RiskFinding
+
+// DefaultRules returns the built-in rule set used by NewAnalyzer
+func DefaultRules() []Rule {
+	return []Rule{
+		privilegeRule,
+		removeRule,
+		diskRule,
+		permissionRule,
+		redirectRule,
+		remoteScriptRule,
+		networkRule,
+		gitRule,
+		systemRule,
+	}
+}
+
+// criticalPaths are paths whose recursive modification breaks the system or wipes user data
+var criticalPaths = []string{
+	"/", "/*", "~", "~/", "~/*", "$HOME", "${HOME}", "$HOME/", "$HOME/*", ".", "./", "./*", "..", "*",
+	"/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib64", "/opt", "/proc", "/root",
+	"/sbin", "/sys", "/usr", "/var",
+}
+
+func isCriticalPath(path string) bool {
+	if slices.Contains(criticalPaths, path) {
+		return true
+	}
+
+	cleaned := filepath.Clean(path)
+	return cleaned != "." && slices.Contains(criticalPaths, cleaned)
+}
+
+func isBlockDevice(path string) bool {
+	for _, prefix := range []string{"/dev/sd", "/dev/hd", "/dev/vd", "/dev/xvd", "/dev/nvme", "/dev/mmcblk", "/dev/disk", "/dev/mapper/"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func finding(cmd SimpleCommand, category domain.RiskCategory, level domain.RiskLevel, reason string) *domain.RiskFinding {
+	source := cmd.Source
+	if source == "" {
+		source = cmd.String()
+	}
+
+	return &domain.RiskFinding{
+		Category: category,
+		Level:    level,
+		Command:  source,
+		Reason:   reason,
+	}
+}
+
+func privilegeRule(cmd SimpleCommand) *domain.RiskFinding {
+	for _, wrapper := range cmd.Wrappers {
+		if wrapper == "sudo" || wrapper == "doas" || wrapper == "pkexec" {
+			return finding(cmd, domain.RiskPrivileged, domain.RiskMedium, fmt.Sprintf("runs %s as root through %s", cmd.Name, wrapper))
+		}
+	}
+
+	if cmd.Name == "su" {
+		return finding(cmd, domain.RiskPrivileged, domain.RiskMedium, "switches to another user, root by default")
+	}
+
+	return nil
+}
+
+func removeRule(cmd SimpleCommand) *domain.RiskFinding {
+	switch cmd.Name {
+	case "rm":
+		recursive := cmd.HasFlag("r", "R", "recursive")
+		for _, target := range cmd.Operands() {
+			if recursive && isCriticalPath(target) {
+				return finding(cmd, domain.RiskDestructive, domain.RiskHigh, fmt.Sprintf("recursively deletes %s", target))
+			}
+		}
+
+		if cmd.HasFlag("no-preserve-root") {
+			return finding(cmd, domain.RiskDestructive, domain.RiskHigh, "disables the protection against deleting /")
+		}
+
+		if recursive {
+			return finding(cmd, domain.RiskDestructive, domain.RiskMedium, "recursively deletes files without moving them to a trash")
+		}
+
+		return finding(cmd, domain.RiskIrreversible, domain.RiskLow, "deletes files without moving them to a trash")
+	case "shred":
+		return finding(cmd, domain.RiskIrreversible, domain.RiskHigh, "overwrites files so they cannot be recovered")
+	case "find":
+		if slices.Contains(cmd.Args, "-delete") {
+			return finding(cmd, domain.RiskDestructive, domain.RiskMedium, "deletes every file matched by find")
+		}
+	case "mv":
+		operands := cmd.Operands()
+		if len(operands) > 0 && operands[len(operands)-1] == "/dev/null" {
+			return finding(cmd, domain.RiskDestructive, domain.RiskHigh, "moves files to /dev/null, destroying them")
+		}
+	case "truncate":
+		return finding(cmd, domain.RiskDestructive, domain.RiskMedium, "truncates file contents")
+	}
+
+	return nil
+}
+
+func diskRule(cmd SimpleCommand) *domain.RiskFinding {
+	switch {
+	case cmd.Name == "dd":
+		for _, arg := range cmd.Args {
+			if target, ok := strings.CutPrefix(arg, "of="); ok && (isBlockDevice(target) || strings.HasPrefix(target, "/dev/")) {
+				return finding(cmd, domain.RiskDestructive, domain.RiskHigh, fmt.Sprintf("writes raw data to the device %s", target))
+			}
+		}
+
+		return finding(cmd, domain.RiskDestructive, domain.RiskMedium, "copies raw data and overwrites the output file")
+	case strings.HasPrefix(cmd.Name, "mkfs"), cmd.Name == "wipefs", cmd.Name == "mkswap":
+		return finding(cmd, domain.RiskIrreversible, domain.RiskHigh, "formats a device, erasing all of its data")
+	case cmd.Name == "fdisk", cmd.Name == "sfdisk", cmd.Name == "parted", cmd.Name == "gdisk", cmd.Name == "sgdisk":
+		return finding(cmd, domain.RiskIrreversible, domain.RiskHigh, "changes the partition table of a disk")
+	}
+
+	return nil
+}
+
+func permissionRule(cmd SimpleCommand) *domain.RiskFinding {
+	if cmd.Name != "chmod" && cmd.Name != "chown" && cmd.Name != "chgrp" {
+		return nil
+	}
+
+	recursive := cmd.HasFlag("R", "recursive")
+	operands := cmd.Operands()
+
+	for _, target := range operands {
+		if recursive && isCriticalPath(target) {
+			return finding(cmd, domain.RiskDestructive, domain.RiskHigh, fmt.Sprintf("recursively changes ownership or permissions of %s", target))
+		}
+	}
+
+	if cmd.Name == "chmod" && len(operands) > 0 && (strings.HasSuffix(operands[0], "777") || strings.Contains(operands[0], "o+w") || strings.Contains(operands[0], "a+w")) {
+		if recursive {
+			return finding(cmd, domain.RiskPrivileged, domain.RiskHigh, "recursively makes files writable by every user")
+		}
+
+		return finding(cmd, domain.RiskPrivileged, domain.RiskMedium, "makes files writable by every user")
+	}
+
+	if recursive {
+		return finding(cmd, domain.RiskPrivileged, domain.RiskLow, "recursively changes ownership or permissions")
+	}
+
+	return nil
+}
+
+func redirectRule(cmd SimpleCommand) *domain.RiskFinding {
+	for _, target := range cmd.Redirects {
+		if isBlockDevice(target) {
+			return finding(cmd, domain.RiskDestructive, domain.RiskHigh, fmt.Sprintf("writes output directly to the device %s", target))
+		}
+
+		if strings.HasPrefix(target, "/etc/") || strings.HasPrefix(target, "/boot/") {
+			return finding(cmd, domain.RiskPrivileged, domain.RiskMedium, fmt.Sprintf("overwrites the system file %s", target))
+		}
+	}
+
+	return nil
+}
+
+func remoteScriptRule(cmd SimpleCommand) *domain.RiskFinding {
+	downloaders := []string{"curl", "wget", "fetch"}
+	interpreters := []string{"sh", "bash", "zsh", "dash", "ksh", "fish", "python", "python3", "perl", "ruby", "node"}
+
+	if slices.Contains(downloaders, cmd.PipedFrom) && slices.Contains(interpreters, cmd.Name) {
+		return finding(cmd, domain.RiskNetwork, domain.RiskHigh, fmt.Sprintf("runs a script downloaded by %s without reviewing it", cmd.PipedFrom))
+	}
+
+	return nil
+}
+
+func networkRule(cmd SimpleCommand) *domain.RiskFinding {
+	switch cmd.Name {
+	case "curl", "wget", "fetch", "scp", "rsync", "ftp", "sftp":
+		return finding(cmd, domain.RiskNetwork, domain.RiskLow, "transfers data over the network")
+	case "ssh", "nc", "ncat", "netcat", "telnet", "socat":
+		return finding(cmd, domain.RiskNetwork, domain.RiskLow, "opens a network connection")
+	case "iptables", "ip6tables", "nft", "ufw":
+		if cmd.HasFlag("F", "flush", "X") || slices.Contains(cmd.Args, "flush") || slices.Contains(cmd.Args, "disable") {
+			return finding(cmd, domain.RiskNetwork, domain.RiskHigh, "removes firewall rules")
+		}
+
+		return finding(cmd, domain.RiskNetwork, domain.RiskMedium, "changes firewall rules")
+	}
+
+	return nil
+}
+
+// gitOptionsWithValue are the options given to git before the subcommand that consume the next argument
+var gitOptionsWithValue = map[string]bool{
+	"-C": true, "-c": true, "--git-dir": true, "--work-tree": true, "--namespace": true,
+}
+
+// gitOperands returns the operands of a git command, from the subcommand on, skipping the options given
+// to git itself. In "git -C repo push" the subcommand is push, not repo
+func gitOperands(cmd SimpleCommand) []string {
+	args := cmd.Args
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") && args[0] != "--" {
+		if gitOptionsWithValue[args[0]] && len(args) > 1 {
+			args = args[2:]
+			continue
+		}
+
+		args = args[1:]
+	}
+
+	return SimpleCommand{Name: cmd.Name, Args: args}.Operands()
+}
+
+func gitRule(cmd SimpleCommand) *domain.RiskFinding {
+	if cmd.Name != "git" {
+		return nil
+	}
+
+	operands := gitOperands(cmd)
+	if len(operands) == 0 {
+		return nil
+	}
+
+	switch operands[0] {
+	case "push":
+		if cmd.HasFlag("f", "force", "force-with-lease", "mirror", "delete") || slices.ContainsFunc(operands[1:], func(op string) bool {
+			return strings.HasPrefix(op, "+") || strings.HasPrefix(op, ":")
+		}) {
+			return finding(cmd, domain.RiskIrreversible, domain.RiskHigh, "rewrites or deletes history on the remote repository")
+		}
+	case "reset":
+		if cmd.HasFlag("hard") {
+			return finding(cmd, domain.RiskIrreversible, domain.RiskMedium, "discards uncommitted changes")
+		}
+	case "clean":
+		if cmd.HasFlag("f", "force") {
+			return finding(cmd, domain.RiskDestructive, domain.RiskMedium, "deletes untracked files")
+		}
+	case "branch":
+		if cmd.HasFlag("D") {
+			return finding(cmd, domain.RiskIrreversible, domain.RiskMedium, "force deletes a branch even if it is not merged")
+		}
+	case "checkout", "restore":
+		if slices.Contains(operands, ".") {
+			return finding(cmd, domain.RiskIrreversible, domain.RiskMedium, "discards uncommitted changes")
+		}
+	}
+
+	return nil
+}
+
+func systemRule(cmd SimpleCommand) *domain.RiskFinding {
+	switch cmd.Name {
+	case "shutdown", "reboot", "halt", "poweroff":
+		return finding(cmd, domain.RiskDestructive, domain.RiskHigh, "stops or restarts the machine")
+	case "kill":
+		if slices.Contains(cmd.Operands(), "-1") || slices.Contains(cmd.Args, "-1") {
+			return finding(cmd, domain.RiskDestructive, domain.RiskHigh, "kills every process you are allowed to signal")
+		}
+	case "killall", "pkill":
+		return finding(cmd, domain.RiskDestructive, domain.RiskMedium, "kills every process matching a name")
+	case "crontab":
+		if cmd.HasFlag("r") {
+			return finding(cmd, domain.RiskIrreversible, domain.RiskHigh, "deletes the whole crontab without confirmation")
+		}
+	case "systemctl":
+		operands := cmd.Operands()
+		if len(operands) > 0 && slices.Contains([]string{"stop", "disable", "mask", "poweroff", "reboot", "halt"}, operands[0]) {
+			return finding(cmd, domain.RiskDestructive, domain.RiskMedium, fmt.Sprintf("runs systemctl %s", operands[0]))
+		}
+	}
+
+	return nil
+}
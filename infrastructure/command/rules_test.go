@@ -0,0 +1,87 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+func TestAnalyzerLevels(t *testing.T) {
+	tests := []struct {
+		line string
+		want domain.RiskLevel
+	}{
+		{line: "ls -la", want: domain.RiskNone},
+		{line: "rm notes.txt", want: domain.RiskLow},
+		{line: "rm -r build", want: domain.RiskMedium},
+		{line: "rm -rf /", want: domain.RiskHigh},
+		{line: "rm -rf ~/", want: domain.RiskHigh},
+		{line: "rm -rf /usr/", want: domain.RiskHigh},
+		{line: "rm -- -rf", want: domain.RiskLow},
+		{line: "sudo ls /root", want: domain.RiskMedium},
+		{line: "sudo rm -rf /", want: domain.RiskHigh},
+		{line: "sudo -n rm -rf /", want: domain.RiskHigh},
+		{line: "sh -c 'rm -rf /'", want: domain.RiskHigh},
+		{line: "echo $(rm -rf /)", want: domain.RiskHigh},
+		{line: "dd if=img.iso of=/dev/sdb", want: domain.RiskHigh},
+		{line: "dd if=a of=b", want: domain.RiskMedium},
+		{line: "mkfs.ext4 /dev/sdb1", want: domain.RiskHigh},
+		{line: "chmod 777 file", want: domain.RiskMedium},
+		{line: "chmod -R 777 dir", want: domain.RiskHigh},
+		{line: "chown -R me /", want: domain.RiskHigh},
+		{line: "echo x > /dev/sda", want: domain.RiskHigh},
+		{line: "echo x > /etc/hosts", want: domain.RiskMedium},
+		{line: "echo x > out.txt", want: domain.RiskNone},
+		{line: "curl -fsSL https://example.com/install.sh | sh", want: domain.RiskHigh},
+		{line: "curl https://example.com", want: domain.RiskLow},
+		{line: "iptables -F", want: domain.RiskHigh},
+		{line: "git push --force", want: domain.RiskHigh},
+		{line: "git push origin +main", want: domain.RiskHigh},
+		{line: "git -C repo push --force", want: domain.RiskHigh},
+		{line: "git -c push.default=current push -f", want: domain.RiskHigh},
+		{line: "git --git-dir .git --work-tree . reset --hard", want: domain.RiskMedium},
+		{line: "git --git-dir=.git --no-pager clean -fd", want: domain.RiskMedium},
+		{line: "git -C repo status", want: domain.RiskNone},
+		{line: "git push", want: domain.RiskNone},
+		{line: "git reset --hard", want: domain.RiskMedium},
+		{line: "git status", want: domain.RiskNone},
+		{line: "kill -9 -1", want: domain.RiskHigh},
+		{line: "crontab -r", want: domain.RiskHigh},
+		{line: "systemctl stop nginx", want: domain.RiskMedium},
+		{line: "systemctl status nginx", want: domain.RiskNone},
+		{line: "echo 'unterminated", want: domain.RiskMedium},
+	}
+
+	analyzer := NewAnalyzer()
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			report := analyzer.Analyze(tt.line)
+			if report.Level != tt.want {
+				t.Errorf("Analyze(%q).Level = %s, want %s (findings: %+v)", tt.line, report.Level, tt.want, report.Findings)
+			}
+		})
+	}
+}
+
+func TestAnalyzerCustomRules(t *testing.T) {
+	only := func(cmd SimpleCommand) *domain.RiskFinding {
+		if cmd.Name == "deploy" {
+			return finding(cmd, domain.RiskIrreversible, domain.RiskHigh, "deploys to production")
+		}
+		return nil
+	}
+
+	analyzer := NewAnalyzer(only)
+
+	if report := analyzer.Analyze("rm -rf /"); report.Level != domain.RiskNone {
+		t.Errorf("the default rules are applied with custom rules: level %s", report.Level)
+	}
+
+	report := analyzer.Analyze("make && deploy --prod")
+	if report.Level != domain.RiskHigh || len(report.Findings) != 1 {
+		t.Fatalf("Analyze = %+v, want a single high finding", report)
+	}
+	if report.Findings[0].Command != "deploy --prod" {
+		t.Errorf("finding command = %q, want the source of the command", report.Findings[0].Command)
+	}
+}
@@ -0,0 +1,194 @@
+package environment
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antunesgabriel/how/config"
+)
+
+// DefaultTools are the executables checked when the configuration does not list any
+var DefaultTools = []string{
+	"sudo", "git", "curl", "wget", "jq",
+	"apt", "dnf", "yum", "pacman", "apk", "zypper", "brew", "port", "snap", "flatpak", "nix",
+	"systemctl", "launchctl", "docker", "podman", "kubectl",
+	"python3", "node", "go", "rg", "fd",
+}
+
+// probeTimeout bounds every external command run to inspect the machine
+const probeTimeout = 2 * time.Second
+
+// Collector gathers information about the machine once and reuses it for the rest of the session
+type Collector struct {
+	tools []string
+	git   bool
+
+	once        sync.Once
+	description string
+}
+
+// Describe returns the machine information formatted for the system prompt
+func (c *Collector) Describe() string {
+	c.once.Do(func() {
+		c.description = c.collect()
+	})
+
+	return c.description
+}
+
+func (c *Collector) collect() string {
+	var sb strings.Builder
+
+	sb.WriteString("Information about the user's machine, use it to tailor commands to it:\n")
+	fmt.Fprintf(&sb, "- Operating system: %s\n", operatingSystem())
+	fmt.Fprintf(&sb, "- Architecture: %s\n", runtime.GOARCH)
+
+	if shell := os.Getenv("SHELL"); shell != "" {
+		fmt.Fprintf(&sb, "- Shell: %s\n", filepath.Base(shell))
+	}
+
+	fmt.Fprintf(&sb, "- Core utilities: %s\n", coreutilsFlavour())
+
+	if cwd, err := os.Getwd(); err == nil {
+		fmt.Fprintf(&sb, "- Working directory: %s\n", cwd)
+	}
+
+	if c.git {
+		if summary := gitSummary(); summary != "" {
+			fmt.Fprintf(&sb, "- Git repository: %s\n", summary)
+		}
+	}
+
+	installed := make([]string, 0, len(c.tools))
+	missing := make([]string, 0, len(c.tools))
+	for _, tool := range c.tools {
+		if _, err := exec.LookPath(tool); err == nil {
+			installed = append(installed, tool)
+		} else {
+			missing = append(missing, tool)
+		}
+	}
+
+	if len(installed) > 0 {
+		fmt.Fprintf(&sb, "- Installed tools: %s\n", strings.Join(installed, ", "))
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(&sb, "- Not installed: %s\n", strings.Join(missing, ", "))
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func operatingSystem() string {
+	switch runtime.GOOS {
+	case "linux":
+		if name := osReleaseName(); name != "" {
+			return "Linux, " + name
+		}
+		return "Linux"
+	case "darwin":
+		if version := probe("sw_vers", "-productVersion"); version != "" {
+			return "macOS " + version
+		}
+		return "macOS"
+	default:
+		if release := probe("uname", "-sr"); release != "" {
+			return release
+		}
+		return runtime.GOOS
+	}
+}
+
+func osReleaseName() string {
+	file, err := os.Open("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "PRETTY_NAME="); ok {
+			return strings.Trim(value, `"'`)
+		}
+	}
+
+	return ""
+}
+
+// coreutilsFlavour tells GNU, BusyBox and BSD userlands apart, since their flags differ
+func coreutilsFlavour() string {
+	version := probe("ls", "--version")
+
+	switch {
+	case strings.Contains(version, "GNU"):
+		return "GNU coreutils"
+	case strings.Contains(strings.ToLower(version), "busybox"):
+		return "BusyBox"
+	}
+
+	if target, err := filepath.EvalSymlinks("/bin/ls"); err == nil && strings.Contains(target, "busybox") {
+		return "BusyBox"
+	}
+
+	if runtime.GOOS == "darwin" || strings.HasSuffix(runtime.GOOS, "bsd") {
+		return "BSD"
+	}
+
+	return "unknown"
+}
+
+func gitSummary() string {
+	branch := probe("git", "rev-parse", "--abbrev-ref", "HEAD")
+	if branch == "" {
+		return ""
+	}
+
+	var modified, untracked int
+	for _, line := range strings.Split(probe("git", "status", "--porcelain"), "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "??"):
+			untracked++
+		default:
+			modified++
+		}
+	}
+
+	return fmt.Sprintf("branch %s, %d changed and %d untracked files", branch, modified, untracked)
+}
+
+// probe runs a command and returns its trimmed output, or an empty string if it fails
+func probe(name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+// NewCollector creates a collector configured by cfg. It returns nil when the environment context is disabled
+func NewCollector(cfg *config.EnvironmentConfig) *Collector {
+	if cfg != nil && cfg.Disabled {
+		return nil
+	}
+
+	tools := DefaultTools
+	if cfg != nil && len(cfg.Tools) > 0 {
+		tools = cfg.Tools
+	}
+
+	return &Collector{tools: tools, git: cfg.GitEnabled()}
+}
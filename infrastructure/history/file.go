@@ -0,0 +1,116 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/antunesgabriel/how/config"
+)
+
+// MaxEntries is the number of prompts kept in the history, older ones are dropped
+const MaxEntries = 1000
+
+// File keeps the prompts sent in the chat as JSON Lines, so multi-line prompts take one line each
+type File struct {
+	path string
+}
+
+// DefaultFilePath returns the path of the history file inside the global configuration directory
+func DefaultFilePath() string {
+	configDir := config.GlobalConfigDirPath()
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "history.jsonl")
+}
+
+// Entries returns the prompts in the history, oldest first. When the file holds more than
+// MaxEntries prompts it is rewritten with the most recent ones
+func (f *File) Entries() ([]string, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+
+	var entries []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry string
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+		if err := f.rewrite(entries); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// Append adds a prompt at the end of the history
+func (f *File) Append(entry string) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding history entry: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening history: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing history: %w", err)
+	}
+
+	return nil
+}
+
+// rewrite replaces the history with the entries, through a temporary file renamed over it
+func (f *File) rewrite(entries []string) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("error encoding history entry: %w", err)
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("error writing history: %w", err)
+	}
+
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("error writing history: %w", err)
+	}
+
+	return nil
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
@@ -2,6 +2,8 @@ package agent
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/cloudwego/eino-ext/components/tool/duckduckgo"
 	einomodel "github.com/cloudwego/eino/components/model"
@@ -10,118 +12,230 @@ import (
 	"github.com/cloudwego/eino/flow/agent/react"
 	"github.com/cloudwego/eino/schema"
 
+	"github.com/antunesgabriel/how/config"
 	"github.com/antunesgabriel/how/domain"
+	"github.com/antunesgabriel/how/infrastructure/prompt"
 )
 
 type Agent struct {
-	agent *react.Agent
+	agent      *react.Agent
+	model      einomodel.BaseChatModel
+	titleModel einomodel.BaseChatModel
+
+	provider  config.Provider
+	modelName string
+	pricing   Pricing
+
+	mu           sync.RWMutex
+	systemPrompt string
+	promptSuffix string
+	environment  domain.Environment
+	limits       ContextLimits
+
+	summaryMu   sync.Mutex
+	summary     string
+	summarized  int
+	summaryHash uint64
 }
 
-func (a *Agent) GetResponse(ctx context.Context, messages []domain.Message) (string, error) {
-	msgs := make([]*schema.Message, len(messages))
+// SetSystemPrompt replaces the system prompt used from the next request on
+func (a *Agent) SetSystemPrompt(prompt string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	for idx, msg := range messages {
-		switch msg.Role {
-		case domain.RoleUser:
-			msgs[idx] = &schema.Message{
-				Role:    schema.User,
-				Content: msg.Content,
-			}
-		case domain.RoleAssistant:
-			msgs[idx] = &schema.Message{
-				Role:    schema.Assistant,
-				Content: msg.Content,
-			}
-		default:
-			msgs[idx] = &schema.Message{
-				Role:    schema.System,
-				Content: msg.Content,
-			}
-		}
+	a.systemPrompt = prompt
+}
+
+// withSystemPrompt prepends the system message to the conversation sent to the model
+func (a *Agent) withSystemPrompt(input []*schema.Message) []*schema.Message {
+	res := make([]*schema.Message, 0, len(input)+1)
+
+	res = append(
+		res,
+		schema.SystemMessage(a.buildSystemPrompt()),
+	)
+	res = append(res, input...)
+	return res
+}
+
+// buildSystemPrompt joins the current system prompt with the tool instructions and the environment description
+func (a *Agent) buildSystemPrompt() string {
+	a.mu.RLock()
+	systemPrompt := a.systemPrompt + a.promptSuffix
+	a.mu.RUnlock()
+
+	if a.environment != nil {
+		systemPrompt += "\n\n" + a.environment.Describe()
+	}
+
+	return systemPrompt
+}
+
+func (a *Agent) GetResponse(ctx context.Context, messages []domain.Message) (domain.Message, error) {
+	msgs := toSchemaMessages(messages)
+
+	var (
+		outMessage *schema.Message
+		err        error
+	)
+
+	ctx, recorder := withUsageRecorder(ctx)
+	ctx, failed := withFailure(ctx)
+	answer := domain.Message{Role: domain.RoleAssistant, CreatedAt: time.Now()}
+
+	if a.agent != nil {
+		outMessage, err = a.agent.Generate(ctx, a.fitContext(ctx, msgs))
+	} else {
+		outMessage, err = a.model.Generate(ctx, a.withSystemPrompt(a.fitContext(ctx, msgs)))
 	}
+	answer.Usage = a.usageOf(recorder)
 
-	outMessage, err := a.agent.Generate(ctx, msgs)
 	if err != nil {
-		return "Sorry, I get an error when I try to do that", err
+		answer.Content = "Sorry, I get an error when I try to do that"
+		return answer, a.classifyError(ctx, failed, err)
 	}
 
 	if outMessage == nil {
-		return "Sorry, I dont know how to do that", nil
+		answer.Content = "Sorry, I dont know how to do that"
+		return answer, nil
 	}
 
-	return outMessage.Content, nil
+	answer.Content = outMessage.Content
+	return answer, nil
 }
 
 func (a *Agent) GetStreamResponse(
 	ctx context.Context,
 	messages []domain.Message,
 ) (domain.StreamResponse, error) {
-	msgs := make([]*schema.Message, len(messages))
+	msgs := toSchemaMessages(messages)
 
-	for idx, msg := range messages {
-		switch msg.Role {
-		case domain.RoleUser:
-			msgs[idx] = &schema.Message{
-				Role:    schema.User,
-				Content: msg.Content,
-			}
-		case domain.RoleAssistant:
-			msgs[idx] = &schema.Message{
-				Role:    schema.Assistant,
-				Content: msg.Content,
-			}
-		default:
-			msgs[idx] = &schema.Message{
-				Role:    schema.System,
-				Content: msg.Content,
-			}
-		}
-	}
+	var (
+		msgReader *schema.StreamReader[*schema.Message]
+		err       error
+	)
 
-	var msgReader *schema.StreamReader[*schema.Message]
+	ctx, recorder := withUsageRecorder(ctx)
+	ctx, failed := withFailure(ctx)
 
-	msgReader, err := a.agent.Stream(ctx, msgs)
+	if a.agent != nil {
+		msgReader, err = a.agent.Stream(ctx, a.fitContext(ctx, msgs))
+	} else {
+		msgReader, err = a.model.Stream(ctx, a.withSystemPrompt(a.fitContext(ctx, msgs)))
+	}
 	if err != nil {
-		return nil, err
+		return nil, a.classifyError(ctx, failed, err)
 	}
 
-	return NewAgentStreamResponse(msgReader), nil
+	stream := NewAgentStreamResponse(msgReader)
+	stream.usage = func() *domain.Usage { return a.usageOf(recorder) }
+	stream.classify = func(err error) error { return a.classifyError(ctx, failed, err) }
+
+	return stream, nil
 }
 
 func NewAgent(
 	ctx context.Context,
 	toolCallingChatModel einomodel.ToolCallingChatModel,
+	mode Mode,
+	opts ...Option,
 ) (*Agent, error) {
-	searchTool, err := duckduckgo.NewTool(ctx, &duckduckgo.Config{})
-	if err != nil {
-		return nil, err
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
 	}
 
-	toolsConfig := compose.ToolsNodeConfig{
-		Tools: []tool.BaseTool{
-			searchTool,
-		},
+	a := &Agent{
+		systemPrompt: prompt.Builtin,
+		promptSuffix: outputInstructions[mode.Output],
+		environment:  o.environment,
+		limits:       o.limits,
+		provider:     o.provider,
+		modelName:    o.modelName,
+		pricing:      o.pricing,
+	}
+
+	toolCallingChatModel = &meteredModel{ToolCallingChatModel: toolCallingChatModel, provider: string(o.provider)}
+	a.model = toolCallingChatModel
+
+	if o.titleModel != nil {
+		a.titleModel = &meteredModel{ToolCallingChatModel: o.titleModel, provider: string(o.provider)}
+	}
+
+	if mode.SystemPrompt != "" {
+		a.systemPrompt = mode.SystemPrompt
+	}
+
+	toolsConfig := compose.ToolsNodeConfig{}
+
+	if mode.hasTool(config.ToolWebSearch) {
+		searchTool, err := duckduckgo.NewTool(ctx, &duckduckgo.Config{})
+		if err != nil {
+			return nil, err
+		}
+
+		toolsConfig.Tools = append(toolsConfig.Tools, searchTool)
+	}
+
+	if o.commandRunner != nil && mode.hasTool(config.ToolExecuteCommand) {
+		toolsConfig.Tools = append(toolsConfig.Tools, newExecuteCommandTool(o.commandRunner))
+		a.promptSuffix += " When you need information about the user's system to answer or to diagnose a problem, use the execute_command tool to propose read-only commands one at a time. The user approves each command before it runs and may decline it."
+	}
+
+	for idx, t := range toolsConfig.Tools {
+		if invokable, ok := t.(tool.InvokableTool); ok {
+			toolsConfig.Tools[idx] = &failureReportingTool{InvokableTool: invokable}
+		}
+	}
+
+	// Providers refuse to bind an empty tool list, so modes without tools call the model directly
+	if len(toolsConfig.Tools) == 0 {
+		return a, nil
 	}
 
 	agent, err := react.NewAgent(ctx, &react.AgentConfig{
 		ToolCallingModel: toolCallingChatModel,
 		ToolsConfig:      toolsConfig,
+		// The default checker only looks at the first chunk and misses the tool calls Claude sends after some text
+		StreamToolCallChecker: streamToolCallChecker(o.provider),
 		MessageModifier: func(ctx context.Context, input []*schema.Message) []*schema.Message {
-			res := make([]*schema.Message, 0, len(input)+1)
-
-			res = append(
-				res,
-				schema.SystemMessage(
-					"You are an expert in shell commands and terminal operations. Your task is search and to provide detailed, accurate explanations of shell commands that users are considering executing. Break down each part of the command, explain what it does, identify any potential risks or side effects, and explain why someone might want to run it. Be specific about what files or systems will be affected. If the command could potentially be harmful, make sure to clearly highlight those risks.",
-				),
-			)
-			res = append(res, input...)
-			return res
+			return a.withSystemPrompt(a.truncateOutputs(input))
 		},
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Agent{agent: agent}, nil
+	a.agent = agent
+	return a, nil
+}
+
+// toSchemaMessages converts the conversation to the messages sent to the model. Errors are only shown
+// to the user, so they are left out
+func toSchemaMessages(messages []domain.Message) []*schema.Message {
+	msgs := make([]*schema.Message, 0, len(messages))
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case domain.RoleUser:
+			msgs = append(msgs, &schema.Message{
+				Role:    schema.User,
+				Content: msg.Content,
+			})
+		case domain.RoleAssistant:
+			msgs = append(msgs, &schema.Message{
+				Role:    schema.Assistant,
+				Content: msg.Content,
+			})
+		case domain.RoleError, domain.RoleNotice:
+			continue
+		default:
+			msgs = append(msgs, &schema.Message{
+				Role:    schema.System,
+				Content: msg.Content,
+			})
+		}
+	}
+
+	return msgs
 }
@@ -0,0 +1,171 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// ContextLimits bound the conversation sent to the model on each request. Zero values disable each limit
+type ContextLimits struct {
+	// MaxTokens is the budget for the system prompt and the messages of a request
+	MaxTokens int
+
+	// ToolOutputTokens limits each command output or tool result
+	ToolOutputTokens int
+
+	// Summarize replaces the turns that no longer fit by a summary written by the model
+	Summarize bool
+}
+
+// messageOverheadTokens approximates the tokens used by the role and the separators of a message
+const messageOverheadTokens = 4
+
+const summaryPrompt = "Summarize the following conversation between a user and a shell assistant in a few sentences. " +
+	"Keep the facts needed to continue it: the user's goal, their system, the commands that were run and their results."
+
+// estimateTokens approximates the tokens of a text at about four characters each, without a provider tokenizer
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text)+3)/4 + messageOverheadTokens
+}
+
+// truncateMiddle shortens a text to about maxTokens, keeping its beginning and end which usually hold
+// the command echo and the final error
+func truncateMiddle(text string, maxTokens int) string {
+	if maxTokens <= 0 || estimateTokens(text) <= maxTokens {
+		return text
+	}
+
+	runes := []rune(text)
+	keep := maxTokens * 4
+	if keep >= len(runes) {
+		return text
+	}
+
+	head := keep * 2 / 3
+	tail := keep - head
+
+	return fmt.Sprintf(
+		"%s\n... [%d characters truncated] ...\n%s",
+		string(runes[:head]),
+		len(runes)-keep,
+		string(runes[len(runes)-tail:]),
+	)
+}
+
+// truncateOutputs limits the size of the command outputs and tool results in the messages
+func (a *Agent) truncateOutputs(msgs []*schema.Message) []*schema.Message {
+	if a.limits.ToolOutputTokens <= 0 {
+		return msgs
+	}
+
+	res := make([]*schema.Message, len(msgs))
+	for idx, msg := range msgs {
+		res[idx] = msg
+
+		if msg.Role != schema.Tool && msg.Role != schema.System {
+			continue
+		}
+
+		if content := truncateMiddle(msg.Content, a.limits.ToolOutputTokens); content != msg.Content {
+			truncated := *msg
+			truncated.Content = content
+			res[idx] = &truncated
+		}
+	}
+
+	return res
+}
+
+// fitContext drops the oldest messages that do not fit the token budget, always keeping the last one.
+// With summarization enabled the dropped messages are replaced by a summary written by the model
+func (a *Agent) fitContext(ctx context.Context, msgs []*schema.Message) []*schema.Message {
+	msgs = a.truncateOutputs(msgs)
+	if a.limits.MaxTokens <= 0 || len(msgs) == 0 {
+		return msgs
+	}
+
+	available := a.limits.MaxTokens - estimateTokens(a.buildSystemPrompt())
+
+	start := len(msgs) - 1
+	used := estimateTokens(msgs[start].Content)
+	for start > 0 {
+		tokens := estimateTokens(msgs[start-1].Content)
+		if used+tokens > available {
+			break
+		}
+
+		used += tokens
+		start--
+	}
+
+	if start == 0 {
+		return msgs
+	}
+
+	// A turn never starts with a tool result, whose tool call would be missing
+	for start < len(msgs)-1 && msgs[start].Role == schema.Tool {
+		start++
+	}
+
+	if !a.limits.Summarize {
+		return msgs[start:]
+	}
+
+	summary := a.summarize(ctx, msgs[:start])
+	if summary == "" || used+estimateTokens(summary) > available {
+		return msgs[start:]
+	}
+
+	res := make([]*schema.Message, 0, len(msgs)-start+1)
+	res = append(res, schema.SystemMessage("Summary of the earlier conversation: "+summary))
+	return append(res, msgs[start:]...)
+}
+
+// summarize returns a summary of the dropped messages. The summary is cached and extended as more
+// messages are dropped, so each message is only summarized once. The cache is keyed by the content of the
+// summarized messages, so it is written again when another conversation or branch is sent to the agent
+func (a *Agent) summarize(ctx context.Context, dropped []*schema.Message) string {
+	a.summaryMu.Lock()
+	defer a.summaryMu.Unlock()
+
+	if len(dropped) < a.summarized || (a.summarized > 0 && hashMessages(dropped[:a.summarized]) != a.summaryHash) {
+		a.summary, a.summarized, a.summaryHash = "", 0, 0
+	}
+	if len(dropped) == a.summarized {
+		return a.summary
+	}
+
+	var sb strings.Builder
+	if a.summary != "" {
+		sb.WriteString("Summary so far: " + a.summary + "\n\n")
+	}
+	for _, msg := range dropped[a.summarized:] {
+		fmt.Fprintf(&sb, "%s: %s\n\n", msg.Role, truncateMiddle(msg.Content, a.limits.ToolOutputTokens))
+	}
+
+	out, err := a.model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(summaryPrompt),
+		schema.UserMessage(sb.String()),
+	})
+	if err != nil || out == nil {
+		return a.summary
+	}
+
+	a.summary, a.summarized, a.summaryHash = strings.TrimSpace(out.Content), len(dropped), hashMessages(dropped)
+	return a.summary
+}
+
+// hashMessages identifies a list of messages by their roles and contents
+func hashMessages(msgs []*schema.Message) uint64 {
+	h := fnv.New64a()
+	for _, msg := range msgs {
+		fmt.Fprintf(h, "%s\x00%s\x00", msg.Role, msg.Content)
+	}
+
+	return h.Sum64()
+}
@@ -0,0 +1,221 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	einomodel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// summaryModel answers every summary request with the same text and records the requests
+type summaryModel struct {
+	answer   string
+	requests []string
+}
+
+func (m *summaryModel) Generate(_ context.Context, input []*schema.Message, _ ...einomodel.Option) (*schema.Message, error) {
+	m.requests = append(m.requests, input[len(input)-1].Content)
+	return schema.AssistantMessage(m.answer, nil), nil
+}
+
+func (m *summaryModel) Stream(context.Context, []*schema.Message, ...einomodel.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not supported")
+}
+
+func TestTruncateMiddle(t *testing.T) {
+	tests := []struct {
+		name      string
+		runes     int
+		maxTokens int
+		truncated bool
+	}{
+		{name: "no limit", runes: 100000, maxTokens: 0, truncated: false},
+		{name: "negative limit", runes: 100000, maxTokens: -1, truncated: false},
+		{name: "empty text", runes: 0, maxTokens: 1, truncated: false},
+		{name: "within the estimate", runes: 7984, maxTokens: 2000, truncated: false},
+		{name: "over the estimate but within the kept characters", runes: 7985, maxTokens: 2000, truncated: false},
+		{name: "exactly the kept characters", runes: 8000, maxTokens: 2000, truncated: false},
+		{name: "one character over", runes: 8001, maxTokens: 2000, truncated: true},
+		{name: "much longer", runes: 100000, maxTokens: 2000, truncated: true},
+		{name: "budget smaller than the overhead", runes: 10, maxTokens: 1, truncated: true},
+		{name: "single token budget", runes: 5, maxTokens: 1, truncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			for idx := range tt.runes {
+				sb.WriteRune(rune('a' + idx%26))
+			}
+			text := sb.String()
+
+			got := truncateMiddle(text, tt.maxTokens)
+			if !tt.truncated {
+				if got != text {
+					t.Fatalf("truncateMiddle changed a text of %d runes with %d tokens", tt.runes, tt.maxTokens)
+				}
+				return
+			}
+
+			keep := tt.maxTokens * 4
+			head, tail := keep*2/3, keep-keep*2/3
+			marker := "\n... [" + strconv.Itoa(tt.runes-keep) + " characters truncated] ...\n"
+
+			if !strings.HasPrefix(got, text[:head]+marker) {
+				t.Errorf("truncateMiddle kept the wrong beginning: %q", got[:min(len(got), 80)])
+			}
+			if !strings.HasSuffix(got, marker+text[len(text)-tail:]) {
+				t.Errorf("truncateMiddle kept the wrong end: %q", got[max(0, len(got)-80):])
+			}
+		})
+	}
+}
+
+func TestTruncateMiddleMultibyte(t *testing.T) {
+	text := strings.Repeat("é", 50) + strings.Repeat("日", 50)
+
+	got := truncateMiddle(text, 5)
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncateMiddle split a character: %q", got)
+	}
+	if !strings.HasPrefix(got, strings.Repeat("é", 13)+"\n") || !strings.HasSuffix(got, "\n"+strings.Repeat("日", 7)) {
+		t.Errorf("truncateMiddle(%q) = %q", text, got)
+	}
+}
+
+func TestTruncateOutputs(t *testing.T) {
+	long := strings.Repeat("x", 400)
+	a := &Agent{limits: ContextLimits{ToolOutputTokens: 10}}
+
+	msgs := []*schema.Message{
+		schema.UserMessage(long),
+		schema.SystemMessage(long),
+		schema.ToolMessage(long, "call"),
+		schema.AssistantMessage(long, nil),
+	}
+
+	got := a.truncateOutputs(msgs)
+	for idx, truncated := range []bool{false, true, true, false} {
+		if (got[idx].Content != long) != truncated {
+			t.Errorf("message %d (%s) truncated = %v, want %v", idx, got[idx].Role, got[idx].Content != long, truncated)
+		}
+	}
+
+	if msgs[1].Content != long || msgs[2].Content != long {
+		t.Error("truncateOutputs modified the messages it was given")
+	}
+}
+
+func TestFitContext(t *testing.T) {
+	// Each message is estimated at 100 tokens: 384 characters and the overhead. The empty system prompt
+	// takes the 4 tokens of the overhead
+	content := func(label string) string {
+		return label + strings.Repeat(".", 384-len(label))
+	}
+
+	conversation := []*schema.Message{
+		schema.UserMessage(content("u1")),
+		schema.AssistantMessage(content("a1"), nil),
+		schema.ToolMessage(content("t1"), "call"),
+		schema.AssistantMessage(content("a2"), nil),
+		schema.UserMessage(content("u2")),
+	}
+
+	tests := []struct {
+		name      string
+		maxTokens int
+		want      []string
+	}{
+		{name: "no limit", maxTokens: 0, want: []string{"u1", "a1", "t1", "a2", "u2"}},
+		{name: "everything fits", maxTokens: 504, want: []string{"u1", "a1", "t1", "a2", "u2"}},
+		{name: "oldest dropped", maxTokens: 503, want: []string{"a1", "t1", "a2", "u2"}},
+		{name: "tool result not first", maxTokens: 300, want: []string{"a2", "u2"}},
+		{name: "last message always kept", maxTokens: 1, want: []string{"u2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Agent{limits: ContextLimits{MaxTokens: tt.maxTokens}}
+
+			got := a.fitContext(context.Background(), conversation)
+			if labels := labelsOf(got); !slices.Equal(labels, tt.want) {
+				t.Errorf("fitContext kept %q, want %q", labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitContextCountsSystemPrompt(t *testing.T) {
+	// The system prompt is estimated at 100 tokens, leaving room for a single message of 100 tokens
+	a := &Agent{
+		systemPrompt: strings.Repeat("s", 384),
+		limits:       ContextLimits{MaxTokens: 250},
+	}
+
+	msgs := []*schema.Message{
+		schema.UserMessage("u1" + strings.Repeat(".", 382)),
+		schema.UserMessage("u2" + strings.Repeat(".", 382)),
+	}
+
+	if labels := labelsOf(a.fitContext(context.Background(), msgs)); !slices.Equal(labels, []string{"u2"}) {
+		t.Errorf("fitContext kept %q, want [u2]", labels)
+	}
+}
+
+func TestFitContextSummarizes(t *testing.T) {
+	model := &summaryModel{answer: "the user builds a Go project"}
+	a := &Agent{model: model, limits: ContextLimits{MaxTokens: 250, Summarize: true}}
+
+	msg := func(label string) *schema.Message {
+		return schema.UserMessage(label + strings.Repeat(".", 382))
+	}
+
+	first := []*schema.Message{msg("u1"), msg("u2"), msg("u3")}
+	got := a.fitContext(context.Background(), first)
+
+	if len(got) != 3 || got[0].Role != schema.System || !strings.HasSuffix(got[0].Content, model.answer) {
+		t.Fatalf("fitContext did not start with the summary: %+v", got)
+	}
+	if labels := labelsOf(got[1:]); !slices.Equal(labels, []string{"u2", "u3"}) {
+		t.Errorf("fitContext kept %q, want [u2 u3]", labels)
+	}
+
+	// The same dropped messages are not summarized again
+	a.fitContext(context.Background(), first)
+	if len(model.requests) != 1 {
+		t.Fatalf("the summary was requested %d times, want 1", len(model.requests))
+	}
+
+	// Only the newly dropped message is sent with the previous summary
+	a.fitContext(context.Background(), append(first, msg("u4")))
+	if len(model.requests) != 2 {
+		t.Fatalf("the summary was requested %d times, want 2", len(model.requests))
+	}
+	if request := model.requests[1]; !strings.Contains(request, model.answer) || strings.Contains(request, "u1") || !strings.Contains(request, "u2") {
+		t.Errorf("the summary was not extended with the dropped message: %q", request)
+	}
+
+	// Another conversation with as many dropped messages is summarized from the start
+	a.fitContext(context.Background(), []*schema.Message{msg("v1"), msg("v2"), msg("v3"), msg("v4")})
+	if len(model.requests) != 3 {
+		t.Fatalf("the summary was requested %d times, want 3", len(model.requests))
+	}
+	if request := model.requests[2]; strings.Contains(request, "Summary so far") || !strings.Contains(request, "v1") {
+		t.Errorf("the summary of another conversation was reused: %q", request)
+	}
+}
+
+func labelsOf(msgs []*schema.Message) []string {
+	labels := make([]string, len(msgs))
+	for idx, msg := range msgs {
+		labels[idx] = msg.Content[:2]
+	}
+
+	return labels
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+
+	"github.com/antunesgabriel/how/domain"
+	llmodel "github.com/antunesgabriel/how/infrastructure/orchestration/model"
+)
+
+type failureKey struct{}
+
+// failure keeps the first error of a model or tool call made while answering a request. The ReAct graph
+// wraps the errors of its nodes without keeping their type, so they are recorded before they get there
+type failure struct {
+	mu  sync.Mutex
+	err *domain.AgentError
+}
+
+func withFailure(ctx context.Context) (context.Context, *failure) {
+	f := &failure{}
+	return context.WithValue(ctx, failureKey{}, f), f
+}
+
+// recordFailure keeps the error in the failure of the context, unless the request was canceled
+func recordFailure(ctx context.Context, err *domain.AgentError) {
+	f, ok := ctx.Value(failureKey{}).(*failure)
+	if !ok || ctx.Err() != nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+// classifyError turns the error of a request into a domain.AgentError, preferring the failure recorded
+// by the model or a tool. Canceled requests return the context error as is
+func (a *Agent) classifyError(ctx context.Context, f *failure, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return ctx.Err()
+	}
+
+	f.mu.Lock()
+	recorded := f.err
+	f.mu.Unlock()
+
+	if recorded != nil {
+		return recorded
+	}
+
+	return llmodel.Classify(string(a.provider), err)
+}
+
+// failureReportingTool records the errors of a tool as tool failures
+type failureReportingTool struct {
+	tool.InvokableTool
+}
+
+func (t *failureReportingTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	out, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	if err != nil {
+		name := "tool"
+		if info, infoErr := t.Info(ctx); infoErr == nil {
+			name = info.Name
+		}
+
+		recordFailure(ctx, &domain.AgentError{
+			Kind: domain.ErrorToolFailure,
+			Hint: llmodel.Hint(domain.ErrorToolFailure, ""),
+			Err:  fmt.Errorf("%s: %w", name, err),
+		})
+	}
+
+	return out, err
+}
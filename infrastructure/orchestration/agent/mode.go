@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"github.com/antunesgabriel/how/config"
+)
+
+// Mode selects the system prompt, the tools and the answer format of the agent.
+// The zero value explains shell commands with every tool available and markdown answers
+type Mode struct {
+	// SystemPrompt replaces the built-in system prompt when not empty
+	SystemPrompt string
+
+	// Tools are the names of the tools the model can call, nil selects all of them
+	Tools []string
+
+	// Output is one of the config.Output* formats, empty selects markdown
+	Output string
+}
+
+// outputInstructions are appended to the system prompt to ask for the mode answer format
+var outputInstructions = map[string]string{
+	config.OutputCommand: " Reply with exactly one shell command, inside a single fenced code block, followed by at most one sentence explaining it. If the request needs several steps, chain them into one command line.",
+	config.OutputPlain:   " Answer in plain text without markdown formatting.",
+}
+
+func (m Mode) hasTool(name string) bool {
+	if m.Tools == nil {
+		return true
+	}
+
+	for _, tool := range m.Tools {
+		if tool == name {
+			return true
+		}
+	}
+
+	return false
+}
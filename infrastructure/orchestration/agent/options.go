@@ -0,0 +1,62 @@
+package agent
+
+import (
+	einomodel "github.com/cloudwego/eino/components/model"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+)
+
+type options struct {
+	commandRunner domain.CommandRunner
+	environment   domain.Environment
+	limits        ContextLimits
+	provider      config.Provider
+	modelName     string
+	pricing       Pricing
+	titleModel    einomodel.ToolCallingChatModel
+}
+
+// Option configures the agent created by NewAgent
+type Option func(o *options)
+
+// WithCommandRunner registers the execute_command tool, which lets the model propose commands
+// that are executed through the runner once the user approves them
+func WithCommandRunner(runner domain.CommandRunner) Option {
+	return func(o *options) {
+		o.commandRunner = runner
+	}
+}
+
+// WithEnvironment appends the description of the user's machine to the system prompt,
+// so the answers use the shell, utilities and package managers available there
+func WithEnvironment(environment domain.Environment) Option {
+	return func(o *options) {
+		o.environment = environment
+	}
+}
+
+// WithContextLimits bounds the conversation sent on each request, trimming old turns and long outputs
+func WithContextLimits(limits ContextLimits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// WithUsage labels the token usage of each answer with the provider and model, and prices it
+// with pricing when it is not nil
+func WithUsage(provider config.Provider, model string, pricing Pricing) Option {
+	return func(o *options) {
+		o.provider = provider
+		o.modelName = model
+		o.pricing = pricing
+	}
+}
+
+// WithTitleModel titles the sessions with a cheaper model than the one answering. Its usage is labelled
+// with the provider and model the fallback model tags its answers with
+func WithTitleModel(model einomodel.ToolCallingChatModel) Option {
+	return func(o *options) {
+		o.titleModel = model
+	}
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/antunesgabriel/how/infrastructure/command"
+)
+
+// ExtractCommand returns the single shell command contained in a model answer.
+// When the answer has a fenced code block its lines are joined with "&&", dropping comments. Blocks with
+// loops, conditionals or functions are written on a single line instead, with their statements separated
+// by ";". Blocks with heredocs cannot be written on one line and return an error.
+// Otherwise the first inline code span or the first non-empty line of the answer is used
+func ExtractCommand(answer string) (string, error) {
+	text := strings.TrimSpace(answer)
+	fromBlock := false
+
+	if start := strings.Index(text, "```"); start >= 0 {
+		block := text[start+3:]
+		if newline := strings.Index(block, "\n"); newline >= 0 {
+			block = block[newline+1:]
+		}
+		if end := strings.Index(block, "```"); end >= 0 {
+			block = block[:end]
+		}
+		text = block
+		fromBlock = true
+	} else if start := strings.Index(text, "`"); start >= 0 {
+		if end := strings.Index(text[start+1:], "`"); end > 0 {
+			text = text[start+1 : start+1+end]
+		}
+	}
+
+	var (
+		commands []string
+		current  string
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "$ ")
+		line = strings.Trim(line, "`")
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if continued, ok := strings.CutSuffix(line, "\\"); ok {
+			current += strings.TrimSpace(continued) + " "
+			continue
+		}
+
+		commands = append(commands, current+line)
+		current = ""
+
+		if !fromBlock {
+			break
+		}
+	}
+
+	if current != "" {
+		commands = append(commands, strings.TrimSpace(current))
+	}
+
+	for _, line := range commands {
+		if fromBlock && !command.Standalone(line) {
+			return command.OneLine(strings.Join(commands, "\n"))
+		}
+	}
+
+	return strings.Join(commands, " && "), nil
+}
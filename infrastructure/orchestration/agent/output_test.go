@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+		err    bool
+	}{
+		{name: "plain line", answer: "ls -la", want: "ls -la"},
+		{name: "first line", answer: "du -sh *\nShows the size of each file.", want: "du -sh *"},
+		{name: "inline code", answer: "Run `git status` to see the changes.", want: "git status"},
+		{name: "prompt prefix", answer: "$ make build", want: "make build"},
+		{
+			name:   "single line block",
+			answer: "Use this:\n```bash\nfind . -name '*.go'\n```\nIt lists the Go files.",
+			want:   "find . -name '*.go'",
+		},
+		{
+			name:   "lines joined",
+			answer: "```sh\n# build it\nmake\n\n$ make install\n```",
+			want:   "make && make install",
+		},
+		{
+			name:   "line continuations",
+			answer: "```\ndocker run \\\n  --rm \\\n  alpine\necho done\n```",
+			want:   "docker run --rm alpine && echo done",
+		},
+		{
+			name:   "trailing continuation",
+			answer: "```\nls \\\n```",
+			want:   "ls",
+		},
+		{
+			name:   "block without closing fence",
+			answer: "```bash\nuname -a",
+			want:   "uname -a",
+		},
+		{
+			name:   "conditional on one line",
+			answer: "```bash\nif [ -f go.mod ]; then\n  # build\n  go build ./...\nfi\n```",
+			want:   "if [ -f go.mod ]; then go build ./...; fi",
+		},
+		{
+			name:   "loop on one line",
+			answer: "```bash\nfor f in *.log; do\n  gzip \"$f\"\ndone\necho done\n```",
+			want:   "for f in *.log; do gzip \"$f\"; done; echo done",
+		},
+		{
+			name:   "function on one line",
+			answer: "```bash\ngreet() {\n  echo hi\n}\ngreet\n```",
+			want:   "greet() { echo hi; }; greet",
+		},
+		{
+			name:   "one line compound joined",
+			answer: "```bash\ncd /tmp\nif true; then echo yes; fi\n```",
+			want:   "cd /tmp && if true; then echo yes; fi",
+		},
+		{
+			name:   "background job on one line",
+			answer: "```bash\nsleep 10 &\nwait\n```",
+			want:   "sleep 10 & wait",
+		},
+		{
+			name:   "heredoc rejected",
+			answer: "```bash\ncat <<EOF > notes.txt\nhello\nEOF\n```",
+			err:    true,
+		},
+		{
+			name:   "unterminated script rejected",
+			answer: "```bash\nif true; then\n  echo yes\n```",
+			err:    true,
+		},
+		{name: "empty answer", answer: "  \n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractCommand(tt.answer)
+			if tt.err {
+				if err == nil {
+					t.Errorf("ExtractCommand(%q) = %q, want an error", tt.answer, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ExtractCommand(%q) returned an error: %v", tt.answer, err)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractCommand(%q) = %q, want %q", tt.answer, got, tt.want)
+			}
+			if strings.Contains(got, "\n") {
+				t.Errorf("ExtractCommand(%q) returned more than one line", tt.answer)
+			}
+		})
+	}
+}
@@ -1,32 +1,86 @@
 package agent
 
 import (
+	"context"
 	"errors"
 	"io"
+	"strings"
 
 	"github.com/cloudwego/eino/schema"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+	llmodel "github.com/antunesgabriel/how/infrastructure/orchestration/model"
 )
 
 type AgentStreamResponse struct {
 	msgReader *schema.StreamReader[*schema.Message]
-	content   string
+	usage     func() *domain.Usage
+	classify  func(err error) error
 }
 
+// Content returns the next chunk of the answer. It reports done once the stream is over,
+// and the stream is closed after it ends or fails
 func (r *AgentStreamResponse) Content() (string, bool, error) {
 	msg, err := r.msgReader.Recv()
-	if err != nil && errors.Is(err, io.EOF) {
-		return r.content, false, nil
+	if errors.Is(err, io.EOF) {
+		r.msgReader.Close()
+		return "", true, nil
 	}
 
 	if err != nil {
-		return "Error on generating response", false, err
+		r.msgReader.Close()
+		if r.classify != nil {
+			err = r.classify(err)
+		}
+		return "", true, err
 	}
 
-	r.content = msg.Content
+	return msg.Content, false, nil
+}
+
+// Usage returns the token usage of the answer once the stream is finished, or nil when it is unknown
+func (r *AgentStreamResponse) Usage() *domain.Usage {
+	if r.usage == nil {
+		return nil
+	}
 
-	return r.content, true, nil
+	return r.usage()
 }
 
 func NewAgentStreamResponse(msgReader *schema.StreamReader[*schema.Message]) *AgentStreamResponse {
 	return &AgentStreamResponse{msgReader: msgReader}
 }
+
+// streamToolCallChecker decides whether a streamed model output calls a tool. Most providers send
+// the tool calls first, so the first chunk with content settles it and the answer streams right away.
+// Claude writes some text before the tool calls, so its whole output is read before deciding
+func streamToolCallChecker(provider config.Provider) func(context.Context, *schema.StreamReader[*schema.Message]) (bool, error) {
+	return func(_ context.Context, sr *schema.StreamReader[*schema.Message]) (bool, error) {
+		defer sr.Close()
+
+		scanAll := provider == config.ProviderClaude
+
+		for {
+			msg, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+
+			if tagged, _ := msg.Extra[llmodel.ExtraProvider].(string); tagged == string(config.ProviderClaude) {
+				scanAll = true
+			}
+
+			if len(msg.ToolCalls) > 0 {
+				return true, nil
+			}
+
+			if !scanAll && strings.TrimSpace(msg.Content) != "" {
+				return false, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+const (
+	// maxTitleWords, maxTitleLength and maxTags bound the title and tags of a session
+	maxTitleWords  = 6
+	maxTitleLength = 60
+	maxTags        = 3
+
+	// titleMessages are the first messages of the conversation the title is written from
+	titleMessages = 4
+
+	titleTimeout = 30 * time.Second
+)
+
+const titlePrompt = "Write a title of at most six words and up to three lowercase one-word tags for the following " +
+	"conversation between a user and a shell assistant. The title names the user's task, not the question form. " +
+	`Answer only with JSON such as {"title": "Free disk space on Ubuntu", "tags": ["disk", "ubuntu"]}.`
+
+// TitleSession writes a title and tags for the conversation with the title model, or the chat model when
+// none is set. The tokens used are returned with the title. When the model cannot be reached or its answer
+// cannot be used, the title and tags are made up locally from the user's prompts
+func (a *Agent) TitleSession(ctx context.Context, messages []domain.Message) (domain.SessionSummary, error) {
+	var sb strings.Builder
+	for _, msg := range firstTurns(messages) {
+		fmt.Fprintf(&sb, "%s: %s\n\n", msg.Role, truncateMiddle(msg.Content, 500))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, titleTimeout)
+	defer cancel()
+
+	model := a.model
+	if a.titleModel != nil {
+		model = a.titleModel
+	}
+
+	ctx, recorder := withUsageRecorder(ctx)
+	out, err := model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(titlePrompt),
+		schema.UserMessage(sb.String()),
+	})
+	usage := a.usageOf(recorder)
+
+	if err != nil && ctx.Err() == context.Canceled {
+		return domain.SessionSummary{Usage: usage}, err
+	}
+
+	summary, ok := domain.SessionSummary{}, false
+	if err == nil && out != nil {
+		summary, ok = parseSummary(out.Content)
+	}
+	if !ok {
+		summary = summarizeLocally(messages)
+	}
+
+	summary.Usage = usage
+	return summary, nil
+}
+
+// LocalTitler titles sessions without calling a model
+type LocalTitler struct{}
+
+func (LocalTitler) TitleSession(_ context.Context, messages []domain.Message) (domain.SessionSummary, error) {
+	return summarizeLocally(messages), nil
+}
+
+// firstTurns returns the first prompts and answers of the conversation
+func firstTurns(messages []domain.Message) []domain.Message {
+	turns := make([]domain.Message, 0, titleMessages)
+	for _, msg := range messages {
+		if len(turns) == titleMessages {
+			break
+		}
+
+		if msg.Role == domain.RoleUser || msg.Role == domain.RoleAssistant {
+			turns = append(turns, msg)
+		}
+	}
+
+	return turns
+}
+
+// parseSummary reads the JSON answer of the model, which may be wrapped in a code block or some text
+func parseSummary(content string) (domain.SessionSummary, bool) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return domain.SessionSummary{}, false
+	}
+
+	var answer struct {
+		Title string   `json:"title"`
+		Tags  []string `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &answer); err != nil {
+		return domain.SessionSummary{}, false
+	}
+
+	title := strings.Trim(strings.TrimSpace(answer.Title), `"'.`)
+	if title == "" {
+		return domain.SessionSummary{}, false
+	}
+
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength])) + "…"
+	}
+
+	return domain.SessionSummary{Title: title, Tags: cleanTags(answer.Tags)}, true
+}
+
+// cleanTags lowercases the tags, joins their words with dashes and drops the repeated ones
+func cleanTags(tags []string) []string {
+	res := make([]string, 0, maxTags)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(tag), "#")), "-"))
+		if tag == "" || slices.Contains(res, tag) {
+			continue
+		}
+
+		res = append(res, tag)
+		if len(res) == maxTags {
+			break
+		}
+	}
+
+	return res
+}
+
+// questionOpenings are dropped from the start of the first prompt, so the title names the task
+var questionOpenings = []string{
+	"tell me about", "tell me how to", "tell me", "can you", "could you", "would you", "please", "help me",
+	"how do i", "how can i", "how to", "how do you", "what is", "what's", "what are", "explain", "show me",
+	"i want to", "i need to", "i would like to", "is there a way to", "the", "a", "an",
+}
+
+// stopWords are never used as tags
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true, "from": true, "into": true,
+	"what": true, "how": true, "why": true, "when": true, "where": true, "which": true, "who": true,
+	"can": true, "could": true, "would": true, "should": true, "will": true, "does": true, "did": true,
+	"are": true, "was": true, "were": true, "have": true, "has": true, "not": true, "but": true, "all": true,
+	"you": true, "your": true, "me": true, "my": true, "its": true, "there": true, "them": true, "they": true,
+	"some": true, "any": true, "about": true, "using": true, "use": true, "want": true, "need": true,
+	"please": true, "tell": true, "show": true, "explain": true, "make": true, "get": true, "like": true,
+	"way": true, "file": true, "files": true, "command": true, "commands": true, "run": true, "just": true,
+	"between": true, "difference": true, "than": true, "then": true, "also": true, "too": true,
+}
+
+// summarizeLocally titles the session with the start of the first prompt and tags it with the words
+// the user repeats the most
+func summarizeLocally(messages []domain.Message) domain.SessionSummary {
+	var prompts []string
+	for _, msg := range messages {
+		if msg.Role == domain.RoleUser {
+			prompts = append(prompts, msg.Content)
+		}
+	}
+
+	if len(prompts) == 0 {
+		return domain.SessionSummary{}
+	}
+
+	return domain.SessionSummary{Title: localTitle(prompts[0]), Tags: localTags(prompts)}
+}
+
+func localTitle(prompt string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	title := line
+
+	// Commands run directly keep their case
+	if command, ok := strings.CutPrefix(line, "run:"); ok {
+		words := strings.Fields(command)
+		return "Run " + strings.Join(words[:min(len(words), maxTitleWords)], " ")
+	}
+
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, opening := range questionOpenings {
+			if rest, ok := cutPrefixFold(title, opening); ok && strings.TrimSpace(rest) != "" {
+				title, trimmed = strings.TrimSpace(rest), true
+			}
+		}
+	}
+
+	words := strings.Fields(strings.TrimRight(title, "?.!:"))
+	if len(words) == 0 {
+		words = strings.Fields(line)
+	}
+	if len(words) > maxTitleWords {
+		words = words[:maxTitleWords]
+	}
+
+	title = strings.Join(words, " ")
+	runes := []rune(title)
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+
+	return string(runes)
+}
+
+// cutPrefixFold removes a prefix, ignoring case, when it ends at a word boundary
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+
+	rest := s[len(prefix):]
+	if rest != "" && !unicode.IsSpace(rune(rest[0])) && !unicode.IsPunct(rune(rest[0])) {
+		return s, false
+	}
+
+	return strings.TrimLeft(rest, " ,"), true
+}
+
+func localTags(prompts []string) []string {
+	counts := map[string]int{}
+	var words []string
+
+	for _, prompt := range prompts {
+		for _, word := range strings.FieldsFunc(strings.ToLower(prompt), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+		}) {
+			word = strings.Trim(word, "-_")
+			if len([]rune(word)) < 3 || stopWords[word] || isNumber(word) {
+				continue
+			}
+
+			if counts[word] == 0 {
+				words = append(words, word)
+			}
+			counts[word]++
+		}
+	}
+
+	// The most repeated words first, the earliest ones on ties
+	slices.SortStableFunc(words, func(a, b string) int {
+		return counts[b] - counts[a]
+	})
+
+	return cleanTags(words)
+}
+
+func isNumber(word string) bool {
+	return strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
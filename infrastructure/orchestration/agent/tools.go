@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+const ExecuteCommandToolName = "execute_command"
+
+type executeCommandInput struct {
+	Command string `json:"command"`
+	Reason  string `json:"reason"`
+}
+
+type executeCommandOutput struct {
+	Approved bool   `json:"approved"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// newExecuteCommandTool creates a tool that asks the user to approve a shell command and returns
+// its real output. The tool call blocks until the user answers, pausing the ReAct loop meanwhile
+func newExecuteCommandTool(runner domain.CommandRunner) tool.InvokableTool {
+	info := &schema.ToolInfo{
+		Name: ExecuteCommandToolName,
+		Desc: "Propose a shell command to run on the user's machine. The user reviews the command and " +
+			"decides whether to execute it. Returns whether it was approved, its exit code and its output. " +
+			"Use it to inspect the system step by step when diagnosing a problem, one command at a time.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"command": {
+				Type:     schema.String,
+				Desc:     "The shell command to execute, as it would be typed in a POSIX shell",
+				Required: true,
+			},
+			"reason": {
+				Type:     schema.String,
+				Desc:     "Why the command is needed, shown to the user before they approve it",
+				Required: true,
+			},
+		}),
+	}
+
+	return utils.NewTool(info, func(ctx context.Context, input executeCommandInput) (executeCommandOutput, error) {
+		result, err := runner.RunCommand(ctx, domain.CommandRequest{
+			Command: input.Command,
+			Reason:  input.Reason,
+		})
+		if err != nil {
+			return executeCommandOutput{}, err
+		}
+
+		return executeCommandOutput{
+			Approved: result.Approved,
+			ExitCode: result.ExitCode,
+			Output:   result.Output,
+			Reason:   result.Reason,
+		}, nil
+	})
+}
@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	einomodel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+	llmodel "github.com/antunesgabriel/how/infrastructure/orchestration/model"
+)
+
+// Pricing returns the price of a model, as config.Config does from its pricing table
+type Pricing interface {
+	Price(provider config.Provider, model string) (config.PriceConfig, bool)
+}
+
+type usageRecorderKey struct{}
+
+// usageRecorder collects the token usage of every model call made while answering a request,
+// including the calls of the ReAct loop and the summarization of old turns
+type usageRecorder struct {
+	mu       sync.Mutex
+	calls    []*schema.TokenUsage
+	provider string
+	model    string
+}
+
+func withUsageRecorder(ctx context.Context) (context.Context, *usageRecorder) {
+	recorder := &usageRecorder{}
+	return context.WithValue(ctx, usageRecorderKey{}, recorder), recorder
+}
+
+// newCall registers a model call in the recorder of the context, if any
+func newCall(ctx context.Context) *schema.TokenUsage {
+	recorder, ok := ctx.Value(usageRecorderKey{}).(*usageRecorder)
+	if !ok {
+		return nil
+	}
+
+	call := &schema.TokenUsage{}
+
+	recorder.mu.Lock()
+	recorder.calls = append(recorder.calls, call)
+	recorder.mu.Unlock()
+
+	return call
+}
+
+// observe merges the usage reported by a response or a stream chunk into the call. Providers report
+// it once at the end or cumulatively on every chunk, so keeping the highest value works for both
+func (r *usageRecorder) observe(call *schema.TokenUsage, usage *schema.TokenUsage) {
+	if call == nil || usage == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	call.PromptTokens = max(call.PromptTokens, usage.PromptTokens)
+	call.CompletionTokens = max(call.CompletionTokens, usage.CompletionTokens)
+	call.TotalTokens = max(call.TotalTokens, usage.TotalTokens, call.PromptTokens+call.CompletionTokens)
+}
+
+// observeAnswer keeps the provider that answered, as tagged by the fallback model
+func (r *usageRecorder) observeAnswer(msg *schema.Message) {
+	if msg == nil || msg.Extra == nil {
+		return
+	}
+
+	provider, _ := msg.Extra[llmodel.ExtraProvider].(string)
+	if provider == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.provider = provider
+	r.model, _ = msg.Extra[llmodel.ExtraModel].(string)
+}
+
+// total returns the usage of all the calls labelled with the provider that answered last,
+// or nil when no provider reported the usage nor was tagged
+func (r *usageRecorder) total() *domain.Usage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := &domain.Usage{Provider: r.provider, Model: r.model}
+	for _, call := range r.calls {
+		usage.PromptTokens += call.PromptTokens
+		usage.CompletionTokens += call.CompletionTokens
+		usage.TotalTokens += call.TotalTokens
+	}
+
+	if usage.TotalTokens == 0 && usage.Provider == "" {
+		return nil
+	}
+
+	return usage
+}
+
+// meteredModel records the token usage of the wrapped model in the recorder of the request context,
+// and its errors as the failure of the request
+type meteredModel struct {
+	einomodel.ToolCallingChatModel
+	provider string
+}
+
+func (m *meteredModel) Generate(ctx context.Context, input []*schema.Message, opts ...einomodel.Option) (*schema.Message, error) {
+	out, err := m.ToolCallingChatModel.Generate(ctx, input, opts...)
+	if err != nil {
+		recordFailure(ctx, llmodel.Classify(m.provider, err))
+		return nil, err
+	}
+
+	if recorder, ok := ctx.Value(usageRecorderKey{}).(*usageRecorder); ok && out != nil {
+		recorder.observeAnswer(out)
+		if out.ResponseMeta != nil {
+			recorder.observe(newCall(ctx), out.ResponseMeta.Usage)
+		}
+	}
+
+	return out, nil
+}
+
+func (m *meteredModel) Stream(ctx context.Context, input []*schema.Message, opts ...einomodel.Option) (*schema.StreamReader[*schema.Message], error) {
+	stream, err := m.ToolCallingChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		recordFailure(ctx, llmodel.Classify(m.provider, err))
+		return nil, err
+	}
+
+	recorder, ok := ctx.Value(usageRecorderKey{}).(*usageRecorder)
+	if !ok {
+		return stream, nil
+	}
+
+	call := newCall(ctx)
+	return schema.StreamReaderWithConvert(stream, func(chunk *schema.Message) (*schema.Message, error) {
+		if chunk != nil {
+			recorder.observeAnswer(chunk)
+		}
+		if chunk != nil && chunk.ResponseMeta != nil {
+			recorder.observe(call, chunk.ResponseMeta.Usage)
+		}
+		return chunk, nil
+	}), nil
+}
+
+func (m *meteredModel) WithTools(tools []*schema.ToolInfo) (einomodel.ToolCallingChatModel, error) {
+	model, err := m.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+
+	return &meteredModel{ToolCallingChatModel: model, provider: m.provider}, nil
+}
+
+// usageOf labels the usage recorded for a request with the model, unless the fallback model
+// tagged the provider that answered, and prices it
+func (a *Agent) usageOf(recorder *usageRecorder) *domain.Usage {
+	usage := recorder.total()
+	if usage == nil {
+		return nil
+	}
+
+	if usage.Provider == "" {
+		usage.Provider, usage.Model = string(a.provider), a.modelName
+	}
+
+	if a.pricing != nil {
+		if price, ok := a.pricing.Price(config.Provider(usage.Provider), usage.Model); ok {
+			usage.Cost = price.Cost(usage.PromptTokens, usage.CompletionTokens)
+		}
+	}
+
+	return usage
+}
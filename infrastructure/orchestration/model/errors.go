@@ -0,0 +1,146 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+)
+
+// The provider SDKs only expose the HTTP status and the error code of the API in their messages,
+// so errors are classified by matching them
+var (
+	authStatus        = regexp.MustCompile(`\b(401|403)\b`)
+	rateLimitStatus   = regexp.MustCompile(`\b429\b`)
+	unavailableStatus = regexp.MustCompile(`\b(500|502|503|504|529)\b`)
+)
+
+// errorMarkers are checked in order, the first kind with a marker in the message wins
+var errorMarkers = []struct {
+	kind    domain.ErrorKind
+	markers []string
+}{
+	{domain.ErrorContextTooLong, []string{
+		"context_length_exceeded", "maximum context length", "context window", "prompt is too long",
+		"exceeds the maximum number of tokens", "input token count", "too many tokens",
+	}},
+	{domain.ErrorAuth, []string{
+		"invalid_api_key", "incorrect api key", "invalid x-api-key", "authentication", "unauthorized",
+		"api key not valid", "api_key_invalid", "permission_denied", "security token included in the request is invalid",
+	}},
+	{domain.ErrorRateLimit, []string{"rate limit", "rate_limit", "too many requests", "resource_exhausted"}},
+	{domain.ErrorUnavailable, []string{"overloaded", "unavailable", "internal server error", "bad gateway"}},
+	{domain.ErrorTimeout, []string{"timeout", "timed out", "deadline exceeded"}},
+	{domain.ErrorNetwork, []string{
+		"connection reset", "connection refused", "unexpected eof", "no such host", "network is unreachable",
+		"tls handshake", "broken pipe",
+	}},
+}
+
+// Classify maps an error returned by a provider to a domain.AgentError with a hint on how to solve it
+func Classify(provider string, err error) *domain.AgentError {
+	var agentErr *domain.AgentError
+	if errors.As(err, &agentErr) {
+		return agentErr
+	}
+
+	kind := classify(err)
+
+	return &domain.AgentError{
+		Kind:     kind,
+		Provider: provider,
+		Hint:     Hint(kind, provider),
+		Err:      err,
+	}
+}
+
+func classify(err error) domain.ErrorKind {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return domain.ErrorTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return domain.ErrorTimeout
+		}
+		return domain.ErrorNetwork
+	}
+
+	message := strings.ToLower(err.Error())
+
+	for _, entry := range errorMarkers {
+		for _, marker := range entry.markers {
+			if strings.Contains(message, marker) {
+				return entry.kind
+			}
+		}
+	}
+
+	switch {
+	case rateLimitStatus.MatchString(message):
+		return domain.ErrorRateLimit
+	case authStatus.MatchString(message):
+		return domain.ErrorAuth
+	case unavailableStatus.MatchString(message):
+		return domain.ErrorUnavailable
+	}
+
+	return domain.ErrorUnknown
+}
+
+// Hint returns what the user can do about an error of the given kind, pointing at the configuration of the provider
+func Hint(kind domain.ErrorKind, provider string) string {
+	switch kind {
+	case domain.ErrorAuth:
+		switch config.Provider(provider) {
+		case config.ProviderClaude:
+			return "check claude.api_key, or claude.access_key and claude.secret_access_key when using Bedrock"
+		case config.ProviderOllama:
+			return "check ollama.base_url"
+		case "":
+			return "check the API key of the provider in the configuration"
+		}
+		return "check " + provider + ".api_key"
+	case domain.ErrorRateLimit:
+		return "the provider is limiting the requests, wait a moment or configure fallback providers"
+	case domain.ErrorUnavailable:
+		return "the provider is unavailable or overloaded, try again later or configure fallback providers"
+	case domain.ErrorTimeout:
+		switch config.Provider(provider) {
+		case config.ProviderOpenAI, config.ProviderDeepseek, config.ProviderOllama:
+			return "the provider took too long to answer, try again or raise " + provider + ".timeout"
+		}
+		return "the provider took too long to answer, try again"
+	case domain.ErrorContextTooLong:
+		return "the conversation does not fit the model, start a new session or lower context.max_tokens"
+	case domain.ErrorNetwork:
+		if config.Provider(provider) == config.ProviderOllama {
+			return "check that Ollama is running at ollama.base_url"
+		}
+		return "check your internet connection and the base_url of the provider"
+	case domain.ErrorToolFailure:
+		return "a tool used by the assistant failed, try again or pick a mode without it with --mode"
+	}
+
+	return ""
+}
+
+// IsRetryable reports whether an error is transient: rate limits, overloaded or unavailable
+// servers, timeouts and dropped connections
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	switch Classify("", err).Kind {
+	case domain.ErrorRateLimit, domain.ErrorUnavailable, domain.ErrorTimeout, domain.ErrorNetwork:
+		return true
+	}
+
+	return false
+}
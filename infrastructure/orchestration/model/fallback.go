@@ -0,0 +1,174 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	einomodel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// ExtraProvider and ExtraModel are the keys of schema.Message.Extra holding the provider
+	// and the model that answered
+	ExtraProvider = "how_provider"
+	ExtraModel    = "how_model"
+)
+
+// Candidate is a provider model tried by FallbackModel
+type Candidate struct {
+	Provider  string
+	Model     string
+	ChatModel einomodel.ToolCallingChatModel
+}
+
+// RetryPolicy controls the retries made with each candidate
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// FallbackModel retries transient errors with exponential backoff and moves to the next candidate
+// when a provider keeps failing. Answers are tagged in Extra with the provider that produced them
+type FallbackModel struct {
+	candidates []Candidate
+	retry      RetryPolicy
+}
+
+func (m *FallbackModel) Generate(ctx context.Context, input []*schema.Message, opts ...einomodel.Option) (*schema.Message, error) {
+	var out *schema.Message
+
+	err := m.try(ctx, func(candidate Candidate) error {
+		var err error
+		out, err = candidate.ChatModel.Generate(ctx, input, opts...)
+		if err != nil {
+			return err
+		}
+
+		if out != nil {
+			tag(out, candidate)
+		}
+		return nil
+	})
+
+	return out, err
+}
+
+// Stream retries while the stream is being opened. Errors in the middle of a stream are returned as is,
+// since part of the answer may have been shown already
+func (m *FallbackModel) Stream(ctx context.Context, input []*schema.Message, opts ...einomodel.Option) (*schema.StreamReader[*schema.Message], error) {
+	var out *schema.StreamReader[*schema.Message]
+
+	err := m.try(ctx, func(candidate Candidate) error {
+		stream, err := candidate.ChatModel.Stream(ctx, input, opts...)
+		if err != nil {
+			return err
+		}
+
+		first := true
+		out = schema.StreamReaderWithConvert(stream, func(chunk *schema.Message) (*schema.Message, error) {
+			if first && chunk != nil {
+				tag(chunk, candidate)
+				first = false
+			}
+			return chunk, nil
+		})
+		return nil
+	})
+
+	return out, err
+}
+
+func (m *FallbackModel) WithTools(tools []*schema.ToolInfo) (einomodel.ToolCallingChatModel, error) {
+	candidates := make([]Candidate, 0, len(m.candidates))
+
+	for _, candidate := range m.candidates {
+		chatModel, err := candidate.ChatModel.WithTools(tools)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", candidate.Provider, err)
+		}
+
+		candidate.ChatModel = chatModel
+		candidates = append(candidates, candidate)
+	}
+
+	return &FallbackModel{candidates: candidates, retry: m.retry}, nil
+}
+
+// try calls fn with each candidate in order until one succeeds, retrying transient errors
+func (m *FallbackModel) try(ctx context.Context, fn func(candidate Candidate) error) error {
+	var errs []error
+
+	for _, candidate := range m.candidates {
+		backoff := m.retry.InitialBackoff
+
+		for attempt := 1; ; attempt++ {
+			err := fn(candidate)
+			if err == nil {
+				return nil
+			}
+
+			if ctx.Err() != nil {
+				return err
+			}
+
+			if !IsRetryable(err) || attempt >= m.retry.MaxAttempts {
+				errs = append(errs, Classify(candidate.Provider, err))
+				break
+			}
+
+			if err := sleep(ctx, jitter(backoff)); err != nil {
+				return err
+			}
+			backoff = min(backoff*2, m.retry.MaxBackoff)
+		}
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+
+	return errors.Join(errs...)
+}
+
+func tag(msg *schema.Message, candidate Candidate) {
+	if msg.Extra == nil {
+		msg.Extra = map[string]any{}
+	}
+
+	msg.Extra[ExtraProvider] = candidate.Provider
+	msg.Extra[ExtraModel] = candidate.Model
+}
+
+// jitter spreads the retries of concurrent clients by up to 20% of the backoff
+func jitter(backoff time.Duration) time.Duration {
+	if backoff <= 0 {
+		return 0
+	}
+
+	return backoff + time.Duration(rand.Int64N(int64(backoff)/5+1))
+}
+
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func NewFallbackModel(retry RetryPolicy, candidates ...Candidate) *FallbackModel {
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+
+	return &FallbackModel{candidates: candidates, retry: retry}
+}
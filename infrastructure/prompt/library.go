@@ -0,0 +1,158 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/antunesgabriel/how/config"
+)
+
+// Builtin is the default system prompt, which explains the shell commands users are considering executing
+const Builtin = "You are an expert in shell commands and terminal operations. Your task is search and to provide detailed, accurate explanations of shell commands that users are considering executing. Break down each part of the command, explain what it does, identify any potential risks or side effects, and explain why someone might want to run it. Be specific about what files or systems will be affected. If the command could potentially be harmful, make sure to clearly highlight those risks."
+
+// builtins are the prompts used by the built-in modes, available without configuration
+var builtins = map[string]string{
+	config.DefaultSystemPrompt: Builtin,
+	"generate": "You are an expert in shell commands and terminal operations. " +
+		"The user describes in natural language what they want to do in their terminal and you write the command that does it. " +
+		"Prefer commands that are safe and available on a typical {{.OS}} system using {{.Shell}}.",
+	"debug": "You are an expert in shell commands and terminal operations helping the user fix an error. " +
+		"Find the most likely cause of the error from the command and its output, then give the corrected command or the steps to fix it. " +
+		"When the cause is unclear, check the state of the system before guessing. Keep the answer short.",
+	"review": "You are an expert in shell scripting reviewing a script written by the user. " +
+		"Point out bugs, unquoted variables, missing error handling, portability issues between GNU and BSD tools and security risks, " +
+		"most severe first, and show the corrected lines. Do not rewrite the parts of the script that are fine.",
+}
+
+// Vars are the variables available to the system prompt templates
+type Vars struct {
+	OS    string
+	Shell string
+	Cwd   string
+	Date  string
+	User  string
+}
+
+// Library holds the system prompt templates by name
+type Library struct {
+	templates map[string]*template.Template
+}
+
+// Names returns the names of the available prompts sorted alphabetically
+func (l *Library) Names() []string {
+	names := make([]string, 0, len(l.templates))
+	for name := range l.templates {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Render executes the named template with the current variables
+func (l *Library) Render(name string) (string, error) {
+	tmpl, ok := l.templates[name]
+	if !ok {
+		return "", fmt.Errorf("system prompt %q not found, available: %s", name, strings.Join(l.Names(), ", "))
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, currentVars()); err != nil {
+		return "", fmt.Errorf("error rendering system prompt %q: %w", name, err)
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func (l *Library) add(name, text string) error {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid system prompt %q: %w", name, err)
+	}
+
+	l.templates[name] = tmpl
+	return nil
+}
+
+// loadDir adds every markdown file in dir as a prompt named after the file
+func (l *Library) loadDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return fmt.Errorf("error listing system prompts: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("error reading system prompt: %w", err)
+		}
+
+		if err := l.add(strings.TrimSuffix(filepath.Base(path), ".md"), string(data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func currentVars() Vars {
+	vars := Vars{
+		OS:    runtime.GOOS,
+		Shell: filepath.Base(os.Getenv("SHELL")),
+		Date:  time.Now().Format("2006-01-02"),
+		User:  os.Getenv("USER"),
+	}
+
+	if vars.Shell == "." {
+		vars.Shell = "sh"
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		vars.Cwd = cwd
+	}
+
+	if u, err := user.Current(); err == nil {
+		vars.User = u.Username
+	}
+
+	return vars
+}
+
+// NewLibrary creates a library with the built-in prompts, the markdown templates in dir and the prompts in the configuration.
+// Prompts in the configuration take precedence over files with the same name, and both can override the built-in ones
+func NewLibrary(prompts map[string]string, dir string) (*Library, error) {
+	library := &Library{templates: map[string]*template.Template{}}
+
+	for name, text := range builtins {
+		if err := library.add(name, text); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := library.loadDir(dir); err != nil {
+		return nil, err
+	}
+
+	for name, text := range prompts {
+		if err := library.add(name, text); err != nil {
+			return nil, err
+		}
+	}
+
+	return library, nil
+}
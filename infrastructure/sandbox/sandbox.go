@@ -0,0 +1,204 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+const (
+	// maxTreeSize is the maximum size of the working directory copied into the sandbox
+	maxTreeSize = 256 << 20
+
+	// maxDiffSize is the maximum size of the diff reported back to the user
+	maxDiffSize = 20000
+
+	// setupFailedMarker is printed by the unshare script when the isolation cannot be set up
+	setupFailedMarker = "how-sandbox-setup-failed"
+)
+
+var ErrUnavailable = errors.New(
+	"sandboxed execution is not available: it requires Linux with bubblewrap (bwrap) installed or unprivileged user namespaces for unshare. The command was not executed",
+)
+
+// unshareScript runs inside new user, mount and network namespaces. It mounts the copy of the
+// working directory over the original one, remounts every other mount read-only and gives the
+// command an empty /tmp, so only the copy can be modified
+const unshareScript = `
+copy="$1"; cwd="$2"; cmd="$3"
+fail() { echo "` + setupFailedMarker + `: $1" >&2; exit 125; }
+mount --bind "$copy" "$cwd" || fail "bind mount"
+for mp in $(awk '{print $5}' /proc/self/mountinfo); do
+	[ "$mp" = "$cwd" ] && continue
+	case "$mp" in /proc|/proc/*|/sys|/sys/*|/dev|/dev/*) continue ;; esac
+	mount -o remount,bind,ro "$mp" 2>/dev/null || fail "read-only remount of $mp"
+done
+case "$cwd" in /tmp|/tmp/*) ;; *) mount -t tmpfs tmpfs /tmp || fail "tmpfs mount" ;; esac
+cd "$cwd" || fail "chdir"
+exec sh -c "$cmd"
+`
+
+type isolation struct {
+	name    string
+	probe   []string
+	command func(ctx context.Context, copyDir, cwd, command string) *exec.Cmd
+}
+
+var isolations = []isolation{
+	{
+		name:  "bubblewrap",
+		probe: []string{"bwrap", "--ro-bind", "/", "/", "--unshare-all", "true"},
+		command: func(ctx context.Context, copyDir, cwd, command string) *exec.Cmd {
+			return exec.CommandContext(
+				ctx,
+				"bwrap",
+				"--ro-bind", "/", "/",
+				"--dev", "/dev",
+				"--proc", "/proc",
+				"--tmpfs", "/tmp",
+				"--bind", copyDir, cwd,
+				"--unshare-all",
+				"--die-with-parent",
+				"--chdir", cwd,
+				"sh", "-c", command,
+			)
+		},
+	},
+	{
+		name:  "unshare",
+		probe: []string{"unshare", "--user", "--map-root-user", "--mount", "--net", "true"},
+		command: func(ctx context.Context, copyDir, cwd, command string) *exec.Cmd {
+			return exec.CommandContext(
+				ctx,
+				"unshare", "--user", "--map-root-user", "--mount", "--net",
+				"sh", "-c", unshareScript, "sh", copyDir, cwd, command,
+			)
+		},
+	},
+}
+
+// Sandbox runs commands against a throwaway copy of the working directory
+type Sandbox struct {
+	timeout time.Duration
+}
+
+// DryRun executes the command in an isolated copy of the working directory and reports the
+// file changes it made. It returns ErrUnavailable when no isolation method works on this system
+func (s *Sandbox) DryRun(command string) (domain.DryRunResult, error) {
+	result := domain.DryRunResult{}
+
+	method, ok := s.detect()
+	if !ok {
+		return result, ErrUnavailable
+	}
+	result.Isolation = method.name
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return result, fmt.Errorf("error getting working directory: %w", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "how-sandbox-")
+	if err != nil {
+		return result, fmt.Errorf("error creating sandbox directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	copyDir := tempDir + "/cwd"
+	if err := copyTree(cwd, copyDir, maxTreeSize); err != nil {
+		return result, fmt.Errorf("error copying working directory: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	cmd := method.command(ctx, copyDir, cwd, command)
+	output, err := cmd.CombinedOutput()
+	result.Output = string(output)
+
+	if strings.Contains(result.Output, setupFailedMarker) {
+		return result, fmt.Errorf("%w (%s)", ErrUnavailable, strings.TrimSpace(result.Output))
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return result, fmt.Errorf("error running sandboxed command: %w", err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+
+	if ctx.Err() != nil {
+		result.Output += fmt.Sprintf("\n(command stopped after %s)", s.timeout)
+	}
+
+	result.Changes, err = compareTrees(cwd, copyDir)
+	if err != nil {
+		return result, err
+	}
+
+	if len(result.Changes) > 0 {
+		result.Diff = diffTrees(cwd, copyDir)
+	}
+
+	return result, nil
+}
+
+func (s *Sandbox) detect() (isolation, bool) {
+	if runtime.GOOS != "linux" {
+		return isolation{}, false
+	}
+
+	for _, method := range isolations {
+		if _, err := exec.LookPath(method.probe[0]); err != nil {
+			continue
+		}
+
+		if err := exec.Command(method.probe[0], method.probe[1:]...).Run(); err != nil {
+			continue
+		}
+
+		return method, true
+	}
+
+	return isolation{}, false
+}
+
+// diffTrees returns a unified diff between both directories, or an empty string when diff is not installed
+func diffTrees(before, after string) string {
+	if _, err := exec.LookPath("diff"); err != nil {
+		return ""
+	}
+
+	output, _ := exec.Command("diff", "-ruN", before, after).Output()
+
+	lines := strings.Split(string(output), "\n")
+	for idx, line := range lines {
+		if strings.HasPrefix(line, "diff ") || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+			line = strings.ReplaceAll(line, after, "b")
+			lines[idx] = strings.ReplaceAll(line, before, "a")
+		}
+	}
+	diff := strings.Join(lines, "\n")
+
+	if len(diff) > maxDiffSize {
+		diff = diff[:maxDiffSize] + "\n... diff truncated ..."
+	}
+
+	return diff
+}
+
+func NewSandbox(timeout time.Duration) *Sandbox {
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+
+	return &Sandbox{timeout: timeout}
+}
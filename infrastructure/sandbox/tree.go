@@ -0,0 +1,183 @@
+package sandbox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+var errTreeTooLarge = errors.New("the working directory is too large to be copied into the sandbox")
+
+// copyTree copies the directories, regular files and symlinks of src into dst.
+// Other file types such as sockets or devices are skipped
+func copyTree(src, dst string, limit int64) error {
+	var total int64
+
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case entry.Type().IsRegular():
+			total += info.Size()
+			if total > limit {
+				return errTreeTooLarge
+			}
+			return copyFile(path, target, info.Mode().Perm())
+		}
+
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+type treeEntry struct {
+	mode fs.FileMode
+	size int64
+	link string
+}
+
+func scanTree(root string) (map[string]treeEntry, error) {
+	entries := map[string]treeEntry{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		current := treeEntry{mode: info.Mode(), size: info.Size()}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			current.link, _ = os.Readlink(path)
+		}
+
+		entries[rel] = current
+		return nil
+	})
+
+	return entries, err
+}
+
+// compareTrees returns the files added, modified or deleted in after compared to before, sorted by path
+func compareTrees(before, after string) ([]domain.FileChange, error) {
+	beforeEntries, err := scanTree(before)
+	if err != nil {
+		return nil, fmt.Errorf("error scanning the working directory: %w", err)
+	}
+
+	afterEntries, err := scanTree(after)
+	if err != nil {
+		return nil, fmt.Errorf("error scanning the sandbox directory: %w", err)
+	}
+
+	changes := make([]domain.FileChange, 0)
+
+	for path, afterEntry := range afterEntries {
+		beforeEntry, ok := beforeEntries[path]
+		if !ok {
+			changes = append(changes, domain.FileChange{Path: path, Kind: domain.FileAdded})
+			continue
+		}
+
+		if changed(filepath.Join(before, path), filepath.Join(after, path), beforeEntry, afterEntry) {
+			changes = append(changes, domain.FileChange{Path: path, Kind: domain.FileModified})
+		}
+	}
+
+	for path, beforeEntry := range beforeEntries {
+		if _, ok := afterEntries[path]; !ok && (beforeEntry.mode.IsDir() || beforeEntry.mode.IsRegular() || beforeEntry.mode&fs.ModeSymlink != 0) {
+			changes = append(changes, domain.FileChange{Path: path, Kind: domain.FileDeleted})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes, nil
+}
+
+func changed(beforePath, afterPath string, before, after treeEntry) bool {
+	if before.mode.IsDir() && after.mode.IsDir() {
+		return false
+	}
+
+	if before.mode != after.mode || before.link != after.link {
+		return true
+	}
+
+	if !before.mode.IsRegular() {
+		return false
+	}
+
+	if before.size != after.size {
+		return true
+	}
+
+	beforeData, err := os.ReadFile(beforePath)
+	if err != nil {
+		return true
+	}
+
+	afterData, err := os.ReadFile(afterPath)
+	if err != nil {
+		return true
+	}
+
+	return !bytes.Equal(beforeData, afterData)
+}
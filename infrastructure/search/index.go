@@ -0,0 +1,258 @@
+package search
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+const (
+	// BM25 parameters: how fast repeated terms stop adding to the score, and how much long texts are penalized
+	k1 = 1.2
+	b  = 0.75
+
+	// prefixWeight scores the terms that only start with a query term, so "ffm" finds "ffmpeg" below exact matches
+	prefixWeight = 0.5
+
+	// snippetBefore and snippetAfter are the characters kept around the first match of a snippet
+	snippetBefore = 40
+	snippetAfter  = 80
+)
+
+// Index is an inverted index over the messages, titles and commands of the sessions, ranked with BM25
+type Index struct {
+	docs     []document
+	postings map[string][]posting
+	terms    int
+}
+
+type document struct {
+	result domain.SearchResult
+	text   string
+	terms  int
+}
+
+type posting struct {
+	doc   int
+	count int
+}
+
+func NewIndex() *Index {
+	return &Index{postings: map[string][]posting{}}
+}
+
+// AddSession indexes the title and tags of the session and each of its messages. Error messages are left out
+func (i *Index) AddSession(session domain.Session) {
+	title := session.Title
+	if title == "" {
+		title = firstPrompt(session)
+	}
+
+	if session.Title != "" || len(session.Tags) > 0 {
+		tags := make([]string, len(session.Tags))
+		for idx, tag := range session.Tags {
+			tags[idx] = "#" + tag
+		}
+
+		i.add(domain.SearchResult{
+			SessionID: session.ID,
+			Title:     title,
+			Message:   -1,
+			Role:      "title",
+			Time:      session.UpdatedAt,
+		}, strings.TrimSpace(session.Title+" "+strings.Join(tags, " ")))
+	}
+
+	for idx, msg := range session.Messages {
+		if msg.Role == domain.RoleError {
+			continue
+		}
+
+		when := msg.CreatedAt
+		if when.IsZero() {
+			when = session.UpdatedAt
+		}
+
+		i.add(domain.SearchResult{
+			SessionID: session.ID,
+			Title:     title,
+			Message:   idx,
+			Role:      msg.Role,
+			Time:      when,
+		}, ansi.Strip(msg.Content))
+	}
+}
+
+// AddCommand indexes a command of the audit log, for the sessions that were not saved or no longer are
+func (i *Index) AddCommand(entry domain.AuditEntry, title string) {
+	i.add(domain.SearchResult{
+		SessionID: entry.SessionID,
+		Title:     title,
+		Message:   -1,
+		Role:      "command",
+		Time:      entry.Timestamp,
+	}, entry.Command)
+}
+
+func (i *Index) add(result domain.SearchResult, text string) {
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return
+	}
+
+	doc := len(i.docs)
+	i.docs = append(i.docs, document{result: result, text: text, terms: len(terms)})
+	i.terms += len(terms)
+
+	counts := map[string]int{}
+	for _, term := range terms {
+		counts[term]++
+	}
+	for term, count := range counts {
+		i.postings[term] = append(i.postings[term], posting{doc: doc, count: count})
+	}
+}
+
+// Search returns the texts matching the query, best first, with a snippet around the first match.
+// Texts matching more of the query terms rank higher, and recent ones first on ties
+func (i *Index) Search(query string, limit int) []domain.SearchResult {
+	queryTerms := slices.Compact(sortedTerms(query))
+	if len(queryTerms) == 0 || len(i.docs) == 0 {
+		return nil
+	}
+
+	avgTerms := float64(i.terms) / float64(len(i.docs))
+	scores := map[int]float64{}
+	matched := map[int]int{}
+
+	for _, queryTerm := range queryTerms {
+		best := map[int]float64{}
+
+		for term, postings := range i.postings {
+			weight := 1.0
+			if term != queryTerm {
+				if len(queryTerm) < 3 || !strings.HasPrefix(term, queryTerm) {
+					continue
+				}
+				weight = prefixWeight
+			}
+
+			idf := math.Log(1 + (float64(len(i.docs))-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+			for _, p := range postings {
+				tf := float64(p.count)
+				norm := tf * (k1 + 1) / (tf + k1*(1-b+b*float64(i.docs[p.doc].terms)/avgTerms))
+				best[p.doc] = max(best[p.doc], weight*idf*norm)
+			}
+		}
+
+		for doc, score := range best {
+			scores[doc] += score
+			matched[doc]++
+		}
+	}
+
+	ranked := make([]int, 0, len(scores))
+	for doc, score := range scores {
+		scores[doc] = score * float64(matched[doc]) / float64(len(queryTerms))
+		ranked = append(ranked, doc)
+	}
+
+	slices.SortFunc(ranked, func(x, y int) int {
+		if scores[x] != scores[y] {
+			if scores[x] > scores[y] {
+				return -1
+			}
+			return 1
+		}
+		return i.docs[y].result.Time.Compare(i.docs[x].result.Time)
+	})
+
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	results := make([]domain.SearchResult, len(ranked))
+	for idx, doc := range ranked {
+		results[idx] = i.docs[doc].result
+		results[idx].Score = scores[doc]
+		results[idx].Snippet = snippet(i.docs[doc].text, queryTerms)
+	}
+
+	return results
+}
+
+// tokenize splits a text in lowercase words of letters and digits, so "ffmpeg -i in.mp4" gives ffmpeg, in and mp4
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return slices.DeleteFunc(words, func(word string) bool {
+		return len([]rune(word)) < 2
+	})
+}
+
+func sortedTerms(query string) []string {
+	terms := tokenize(query)
+	slices.Sort(terms)
+	return terms
+}
+
+// snippet returns the line of text around the first match of the terms, on a single line
+func snippet(text string, terms []string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+
+	// Lowercased rune by rune, so the positions match the original text
+	lower := make([]rune, len(runes))
+	for idx, r := range runes {
+		lower[idx] = unicode.ToLower(r)
+	}
+
+	start := -1
+	for _, term := range terms {
+		if pos := indexRunes(lower, []rune(term)); pos >= 0 && (start < 0 || pos < start) {
+			start = pos
+		}
+	}
+	start = max(start, 0)
+
+	from := max(start-snippetBefore, 0)
+	to := min(start+snippetAfter, len(runes))
+
+	res := string(runes[from:to])
+	if from > 0 {
+		res = "…" + res
+	}
+	if to < len(runes) {
+		res += "…"
+	}
+
+	return res
+}
+
+func indexRunes(s, sub []rune) int {
+	for idx := 0; idx+len(sub) <= len(s); idx++ {
+		if slices.Equal(s[idx:idx+len(sub)], sub) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// firstPrompt returns the first line of the first prompt of the session
+func firstPrompt(session domain.Session) string {
+	for _, msg := range session.Messages {
+		if msg.Role == domain.RoleUser {
+			line, _, _ := strings.Cut(strings.TrimSpace(msg.Content), "\n")
+			return line
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,228 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// commandIndex indexes each command as a separate session named after its position, the later ones being more recent
+func commandIndex(commands ...string) *Index {
+	index := NewIndex()
+	for idx, cmd := range commands {
+		index.AddCommand(domain.AuditEntry{
+			SessionID: string(rune('a' + idx)),
+			Command:   cmd,
+			Timestamp: start.Add(time.Duration(idx) * time.Hour),
+		}, "")
+	}
+
+	return index
+}
+
+func sessionIDs(results []domain.SearchResult) []string {
+	ids := make([]string, len(results))
+	for idx, result := range results {
+		ids[idx] = result.SessionID
+	}
+
+	return ids
+}
+
+func TestIndexRanking(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		query    string
+		limit    int
+		want     []string
+	}{
+		{
+			name:     "more query terms matched first",
+			commands: []string{"git status", "git rebase -i main", "git log"},
+			query:    "git rebase",
+			want:     []string{"b", "c", "a"},
+		},
+		{
+			name:     "rare terms weigh more",
+			commands: []string{"docker ps", "docker images", "docker run", "compose up"},
+			query:    "docker compose",
+			want:     []string{"d", "c", "b", "a"},
+		},
+		{
+			name:     "shorter texts rank higher",
+			commands: []string{"tar -czf backup.tar.gz /home/me/documents /home/me/pictures", "tar -xzf backup.tar.gz"},
+			query:    "tar",
+			want:     []string{"b", "a"},
+		},
+		{
+			name:     "repeated terms rank higher",
+			commands: []string{"echo one two", "echo echo two"},
+			query:    "echo",
+			want:     []string{"b", "a"},
+		},
+		{
+			name:     "exact terms before prefixes",
+			commands: []string{"ffmpegthumbnailer -i in.mp4", "ffmpeg -i in.mp4"},
+			query:    "ffmpeg",
+			want:     []string{"b", "a"},
+		},
+		{
+			name:     "prefix matches",
+			commands: []string{"ffmpeg -i in.mp4", "ls"},
+			query:    "ffm",
+			want:     []string{"a"},
+		},
+		{
+			name:     "short prefixes do not match",
+			commands: []string{"ffmpeg -i in.mp4"},
+			query:    "ff",
+			want:     []string{},
+		},
+		{
+			name:     "recent first on ties",
+			commands: []string{"make build", "make build", "make build"},
+			query:    "make",
+			want:     []string{"c", "b", "a"},
+		},
+		{
+			name:     "case insensitive",
+			commands: []string{"echo Hello", "ls"},
+			query:    "HELLO",
+			want:     []string{"a"},
+		},
+		{
+			name:     "limit",
+			commands: []string{"make a1", "make a2", "make a3"},
+			query:    "make",
+			limit:    2,
+			want:     []string{"c", "b"},
+		},
+		{
+			name:     "no terms",
+			commands: []string{"ls"},
+			query:    "- x",
+			want:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := commandIndex(tt.commands...).Search(tt.query, tt.limit)
+			if got := sessionIDs(results); !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+
+			for idx := 1; idx < len(results); idx++ {
+				if results[idx].Score > results[idx-1].Score {
+					t.Errorf("result %d scores %f, more than the result before it", idx, results[idx].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexEmpty(t *testing.T) {
+	if results := NewIndex().Search("ls", 10); len(results) != 0 {
+		t.Errorf("an empty index returned %d results", len(results))
+	}
+}
+
+func TestIndexAddSession(t *testing.T) {
+	session := domain.Session{
+		ID:        "s1",
+		Tags:      []string{"video"},
+		UpdatedAt: start,
+		Messages: []domain.Message{
+			{Role: domain.RoleUser, Content: "how do I convert a video?\nwith ffmpeg"},
+			{Role: domain.RoleAssistant, Content: "Use \x1b[1mffmpeg\x1b[0m -i in.mov out.mp4", CreatedAt: start.Add(time.Minute)},
+			{Role: domain.RoleError, Content: "ffmpeg failed"},
+		},
+	}
+
+	index := NewIndex()
+	index.AddSession(session)
+
+	results := index.Search("ffmpeg", 0)
+	if len(results) != 2 {
+		t.Fatalf("Search returned %d results, want the prompt and the answer: %+v", len(results), results)
+	}
+
+	for _, result := range results {
+		if result.Role == domain.RoleError {
+			t.Errorf("an error message was indexed: %+v", result)
+		}
+		if result.Title != "how do I convert a video?" {
+			t.Errorf("Title = %q, want the first line of the first prompt", result.Title)
+		}
+	}
+
+	answer := results[slices.IndexFunc(results, func(r domain.SearchResult) bool { return r.Role == domain.RoleAssistant })]
+	if answer.Message != 1 || !answer.Time.Equal(start.Add(time.Minute)) {
+		t.Errorf("answer result = %+v, want message 1 at its creation time", answer)
+	}
+	if strings.Contains(answer.Snippet, "\x1b") {
+		t.Errorf("the snippet kept the escape sequences: %q", answer.Snippet)
+	}
+
+	tags := index.Search("video", 0)
+	if !slices.ContainsFunc(tags, func(r domain.SearchResult) bool { return r.Role == "title" && r.Message == -1 }) {
+		t.Errorf("the tags of the session were not indexed: %+v", tags)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("a ", 50) + "needle" + strings.Repeat(" b", 60)
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{name: "short text", text: "run  ls\n-la", terms: []string{"ls"}, want: "run ls -la"},
+		{name: "no match starts at the beginning", text: "abc", terms: []string{"zz"}, want: "abc"},
+		{name: "case insensitive", text: "Hello World", terms: []string{"world"}, want: "Hello World"},
+		{
+			name:  "long text",
+			text:  long,
+			terms: []string{"needle"},
+			want:  "…" + long[100-snippetBefore:100+snippetAfter] + "…",
+		},
+		{name: "multibyte", text: "café à la crème", terms: []string{"crème"}, want: "café à la crème"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snippet(tt.text, tt.terms); got != tt.want {
+				t.Errorf("snippet(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "ffmpeg -i in.mp4", want: []string{"ffmpeg", "in", "mp4"}},
+		{text: "Git STATUS", want: []string{"git", "status"}},
+		{text: "a b c", want: []string{}},
+		{text: "café crème", want: []string{"café", "crème"}},
+		{text: "", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := tokenize(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
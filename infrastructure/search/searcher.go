@@ -0,0 +1,99 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/antunesgabriel/how/domain"
+	"github.com/antunesgabriel/how/infrastructure/audit"
+	"github.com/antunesgabriel/how/infrastructure/session"
+)
+
+// Searcher searches the saved sessions and the commands of the audit log. The index is built on the
+// first search and again only when the sessions directory or the audit log change
+type Searcher struct {
+	sessionsDir string
+	auditPath   string
+
+	mu      sync.Mutex
+	index   *Index
+	version string
+}
+
+// Search returns the messages, titles and commands matching the query, best first
+func (s *Searcher) Search(query string, limit int) ([]domain.SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if version := s.currentVersion(); s.index == nil || version != s.version {
+		index, err := s.build()
+		if err != nil {
+			return nil, err
+		}
+
+		s.index, s.version = index, version
+	}
+
+	return s.index.Search(query, limit), nil
+}
+
+func (s *Searcher) build() (*Index, error) {
+	sessions, err := session.NewStore(s.sessionsDir).List()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := audit.NewLog(s.auditPath).Query(audit.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	index := NewIndex()
+	saved := make(map[string]domain.Session, len(sessions))
+	for _, stored := range sessions {
+		index.AddSession(stored)
+		saved[stored.ID] = stored
+	}
+
+	// Commands run from a saved session are already in its messages
+	for _, entry := range entries {
+		if stored, ok := saved[entry.SessionID]; ok && mentions(stored, entry.Command) {
+			continue
+		}
+
+		index.AddCommand(entry, firstPrompt(saved[entry.SessionID]))
+	}
+
+	return index, nil
+}
+
+// currentVersion identifies the state of the sessions and the audit log by their modification times.
+// Sessions are saved through a rename, which always changes the directory
+func (s *Searcher) currentVersion() string {
+	var version strings.Builder
+	for _, path := range []string{s.sessionsDir, s.auditPath} {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&version, "%d:%d;", info.ModTime().UnixNano(), info.Size())
+		} else {
+			version.WriteString("-;")
+		}
+	}
+
+	return version.String()
+}
+
+func mentions(stored domain.Session, command string) bool {
+	for _, msg := range stored.Messages {
+		if strings.Contains(msg.Content, command) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func NewSearcher(sessionsDir, auditPath string) *Searcher {
+	return &Searcher{sessionsDir: sessionsDir, auditPath: auditPath}
+}
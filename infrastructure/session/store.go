@@ -0,0 +1,141 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/antunesgabriel/how/config"
+	"github.com/antunesgabriel/how/domain"
+)
+
+// ErrNotFound is returned by Load when no session has the given ID
+var ErrNotFound = errors.New("session not found")
+
+// Store keeps each session as a JSON file named after its ID
+type Store struct {
+	dir string
+}
+
+// DefaultDirPath returns the path of the sessions directory inside the global configuration directory
+func DefaultDirPath() string {
+	configDir := config.GlobalConfigDirPath()
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "sessions")
+}
+
+// Save writes the session, replacing the previous version. The file is written to a temporary
+// path first and renamed, so a crash never leaves a truncated session behind
+func (s *Store) Save(session domain.Session) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("error creating sessions directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding session: %w", err)
+	}
+
+	path := s.path(session.ID)
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("error writing session: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error writing session: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads the session with the given ID, or the only session whose ID starts with it
+func (s *Store) Load(id string) (domain.Session, error) {
+	session, err := s.read(s.path(id))
+	if err == nil {
+		return session, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return domain.Session{}, err
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(s.dir, filepath.Base(id)+"*.json"))
+	if len(paths) == 1 {
+		return s.read(paths[0])
+	}
+	if len(paths) > 1 {
+		return domain.Session{}, fmt.Errorf("session ID %q is ambiguous", id)
+	}
+
+	return domain.Session{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// List returns all the sessions, most recently updated first
+func (s *Store) List() ([]domain.Session, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing sessions: %w", err)
+	}
+
+	sessions := make([]domain.Session, 0, len(paths))
+	for _, path := range paths {
+		session, err := s.read(path)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+
+	return sessions, nil
+}
+
+// Delete removes the session with the given ID
+func (s *Store) Delete(id string) error {
+	if err := os.Remove(s.path(id)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return fmt.Errorf("error deleting session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) read(path string) (domain.Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return domain.Session{}, ErrNotFound
+		}
+		return domain.Session{}, fmt.Errorf("error reading session: %w", err)
+	}
+
+	var session domain.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return domain.Session{}, fmt.Errorf("error parsing session %s: %w", filepath.Base(path), err)
+	}
+
+	return session, nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
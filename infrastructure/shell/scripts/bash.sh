@@ -0,0 +1,72 @@
+# how shell integration for bash
+# Add to ~/.bashrc: eval "$(how shell-init bash)"
+# Set HOW_CAPTURE_STDERR=1 before it to also capture the error output of the last command.
+
+__how_state_dir="${HOW_STATE_DIR:-$HOME/.how/shell}"
+mkdir -p "$__how_state_dir"
+
+# Bash writes the prompt and the line being typed to stderr too, so stderr only goes through tee while a
+# command runs: __how_preexec switches to it from the DEBUG trap and __how_precmd switches back
+if [ -n "$HOW_CAPTURE_STDERR" ]; then
+	: > "$__how_state_dir/stderr.live"
+	exec {__how_stderr}>&2 {__how_stderr_tee}> >(tee -a "$__how_state_dir/stderr.live" >&2)
+
+	__how_preexec() {
+		# Skip the prompt commands, key bindings and completions, which run at the prompt
+		[ -n "$__how_at_prompt" ] || return
+		[ -n "${READLINE_POINT+x}" ] || [ -n "${COMP_LINE+x}" ] && return
+
+		__how_at_prompt=
+		exec 2>&"$__how_stderr_tee"
+	}
+
+	__how_prompt_ready() {
+		__how_at_prompt=1
+	}
+
+	__how_debug_trap=$(trap -p DEBUG)
+	__how_debug_trap=${__how_debug_trap#trap -- }
+	eval "__how_debug_trap=${__how_debug_trap% DEBUG}"
+	trap "__how_preexec${__how_debug_trap:+; $__how_debug_trap}" DEBUG
+fi
+
+__how_precmd() {
+	local exit_status=$?
+	[ -n "$HOW_CAPTURE_STDERR" ] && exec 2>&"$__how_stderr"
+	local last_command
+	last_command=$(HISTTIMEFORMAT= builtin history 1 | sed 's/^ *[0-9][0-9]* *//')
+
+	case "$last_command" in
+	"" | how | "how "*) ;;
+	*)
+		printf '%s\n' "$last_command" > "$__how_state_dir/command"
+		printf '%s\n' "$exit_status" > "$__how_state_dir/status"
+		printf '%s\n' "$PWD" > "$__how_state_dir/cwd"
+		if [ -n "$HOW_CAPTURE_STDERR" ]; then
+			cp "$__how_state_dir/stderr.live" "$__how_state_dir/stderr"
+		else
+			: > "$__how_state_dir/stderr"
+		fi
+		;;
+	esac
+
+	[ -n "$HOW_CAPTURE_STDERR" ] && : > "$__how_state_dir/stderr.live"
+	return $exit_status
+}
+
+PROMPT_COMMAND="__how_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+[ -n "$HOW_CAPTURE_STDERR" ] && PROMPT_COMMAND="$PROMPT_COMMAND;__how_prompt_ready"
+
+# Type a request in natural language and press Ctrl-G (or HOW_SUGGEST_KEY, in readline notation such
+# as "\C-g") to replace it with the suggested command.
+__how_suggest_widget() {
+	[ -z "$READLINE_LINE" ] && return
+	local suggestion
+	suggestion=$(how suggest --raw -- "$READLINE_LINE" 2>/dev/null </dev/null) || return
+	if [ -n "$suggestion" ]; then
+		READLINE_LINE="$suggestion"
+		READLINE_POINT=${#READLINE_LINE}
+	fi
+}
+
+[[ $- == *i* ]] && bind -x "\"${HOW_SUGGEST_KEY:-\C-g}\": __how_suggest_widget"
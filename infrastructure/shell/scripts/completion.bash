@@ -0,0 +1,62 @@
+# bash completion for how
+# Add to ~/.bashrc: eval "$(how completion bash)"
+
+_how() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+	case "$prev" in
+	--profile)
+		COMPREPLY=($(compgen -W "$(how __complete profiles 2>/dev/null)" -- "$cur"))
+		return
+		;;
+	--provider)
+		COMPREPLY=($(compgen -W "$(how __complete providers 2>/dev/null)" -- "$cur"))
+		return
+		;;
+	--mode)
+		COMPREPLY=($(compgen -W "$(how __complete modes 2>/dev/null)" -- "$cur"))
+		return
+		;;
+	--session | --resume)
+		COMPREPLY=($(compgen -W "$(how __complete sessions 2>/dev/null)" -- "$cur"))
+		return
+		;;
+	--by)
+		COMPREPLY=($(compgen -W "model day session" -- "$cur"))
+		return
+		;;
+	--model | --since | --grep | --limit)
+		return
+		;;
+	esac
+
+	local sub="" i
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case "${COMP_WORDS[i]}" in
+		--profile | --provider | --model | --mode | --resume) ((i++)) ;;
+		-*) ;;
+		*)
+			sub="${COMP_WORDS[i]}"
+			break
+			;;
+		esac
+	done
+
+	local words
+	case "$sub" in
+	"") words="$(how __complete commands 2>/dev/null) --profile --provider --model --mode --resume" ;;
+	init) words="--local" ;;
+	audit) words="--since --session --grep --failed --limit --json" ;;
+	usage) words="--since --by" ;;
+	search) words="--limit --json" ;;
+	policy) words="test" ;;
+	suggest) words="--raw" ;;
+	shell-init | completion) words="bash zsh fish" ;;
+	*) return ;;
+	esac
+
+	COMPREPLY=($(compgen -W "$words" -- "$cur"))
+}
+
+complete -F _how how
@@ -0,0 +1,34 @@
+# fish completion for how
+# Add to ~/.config/fish/config.fish: how completion fish | source
+
+complete -c how -f
+
+for line in (how __complete commands --describe 2>/dev/null)
+    set -l parts (string split -m 1 ':' -- $line)
+    complete -c how -n __fish_use_subcommand -a $parts[1] -d $parts[2]
+end
+
+complete -c how -l profile -x -a '(how __complete profiles 2>/dev/null)' -d 'Profile to use'
+complete -c how -l provider -x -a '(how __complete providers 2>/dev/null)' -d 'Provider to use'
+complete -c how -l model -x -d 'Provider model to use'
+complete -c how -l mode -x -a '(how __complete modes 2>/dev/null)' -d 'Agent mode to use'
+complete -c how -l resume -x -a '(how __complete sessions 2>/dev/null)' -d 'Session to continue'
+
+complete -c how -n '__fish_seen_subcommand_from init' -l local -d 'Create the configuration in the current directory'
+
+complete -c how -n '__fish_seen_subcommand_from audit' -l since -x -d 'Only commands after this time, e.g. 24h or 7d'
+complete -c how -n '__fish_seen_subcommand_from audit' -l session -x -a '(how __complete sessions 2>/dev/null)' -d 'Only commands of this session'
+complete -c how -n '__fish_seen_subcommand_from audit' -l grep -x -d 'Only commands containing this text'
+complete -c how -n '__fish_seen_subcommand_from audit' -l failed -d 'Only failed commands'
+complete -c how -n '__fish_seen_subcommand_from audit' -l limit -x -d 'Maximum number of commands'
+complete -c how -n '__fish_seen_subcommand_from audit' -l json -d 'Print as JSON Lines'
+
+complete -c how -n '__fish_seen_subcommand_from usage' -l since -x -d 'Only answers after this time, e.g. 24h or 7d'
+complete -c how -n '__fish_seen_subcommand_from usage' -l by -x -a 'model day session' -d 'Group the usage'
+
+complete -c how -n '__fish_seen_subcommand_from search' -l limit -x -d 'Maximum number of results'
+complete -c how -n '__fish_seen_subcommand_from search' -l json -d 'Print as JSON Lines'
+
+complete -c how -n '__fish_seen_subcommand_from policy' -a test -d 'Check which rule applies to a command'
+complete -c how -n '__fish_seen_subcommand_from suggest' -l raw -d 'Print only the command'
+complete -c how -n '__fish_seen_subcommand_from shell-init completion' -a 'bash zsh fish'
@@ -0,0 +1,32 @@
+# how shell integration for fish
+# Add to ~/.config/fish/config.fish: how shell-init fish | source
+# Capturing the error output of the last command is not supported in fish.
+
+set -q HOW_STATE_DIR; or set -g HOW_STATE_DIR $HOME/.how/shell
+mkdir -p $HOW_STATE_DIR
+
+function __how_postexec --on-event fish_postexec
+    set -l exit_status $status
+    string match -qr '^\s*(how(\s|$)|$)' -- $argv[1]; and return
+
+    printf '%s\n' $argv[1] >$HOW_STATE_DIR/command
+    printf '%s\n' $exit_status >$HOW_STATE_DIR/status
+    printf '%s\n' $PWD >$HOW_STATE_DIR/cwd
+    printf '' >$HOW_STATE_DIR/stderr
+end
+
+# Type a request in natural language and press Ctrl-G (or HOW_SUGGEST_KEY, in bind notation such
+# as \cg) to replace it with the suggested command.
+function __how_suggest_widget
+    set -l request (commandline)
+    test -z "$request"; and return
+    set -l suggestion (how suggest --raw -- $request 2>/dev/null </dev/null); or return
+    if test -n "$suggestion"
+        commandline -r -- (string join \n -- $suggestion)
+        commandline -f end-of-line
+    end
+    commandline -f repaint
+end
+
+set -q HOW_SUGGEST_KEY; or set -l HOW_SUGGEST_KEY \cg
+bind $HOW_SUGGEST_KEY __how_suggest_widget
@@ -0,0 +1,118 @@
+package shell
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/antunesgabriel/how/config"
+)
+
+//go:embed scripts
+var scripts embed.FS
+
+// Supported are the shells an integration script exists for
+var Supported = []string{"bash", "zsh", "fish"}
+
+// maxStderrLines is the number of trailing lines of captured error output kept for the agent
+const maxStderrLines = 60
+
+var ErrNoLastCommand = errors.New("no command recorded yet")
+
+// LastCommand is the last command run in a shell with the integration script loaded
+type LastCommand struct {
+	Command  string
+	ExitCode int
+	Cwd      string
+	Stderr   string
+}
+
+// InitScript returns the integration script of the given shell
+func InitScript(shell string) (string, error) {
+	var name string
+
+	switch shell {
+	case "bash":
+		name = "scripts/bash.sh"
+	case "zsh":
+		name = "scripts/zsh.zsh"
+	case "fish":
+		name = "scripts/fish.fish"
+	default:
+		return "", fmt.Errorf("unsupported shell %q, supported shells: %s", shell, strings.Join(Supported, ", "))
+	}
+
+	data, err := scripts.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// CompletionScript returns the completion script of the how CLI for the given shell
+func CompletionScript(shell string) (string, error) {
+	if !slices.Contains(Supported, shell) {
+		return "", fmt.Errorf("unsupported shell %q, supported shells: %s", shell, strings.Join(Supported, ", "))
+	}
+
+	data, err := scripts.ReadFile("scripts/completion." + shell)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// StateDirPath returns the directory the integration scripts write the last command to.
+// It can be changed with the HOW_STATE_DIR environment variable
+func StateDirPath() string {
+	if dir := os.Getenv("HOW_STATE_DIR"); dir != "" {
+		return dir
+	}
+
+	configDir := config.GlobalConfigDirPath()
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "shell")
+}
+
+// ReadLastCommand reads the last command recorded by the integration scripts in dir
+func ReadLastCommand(dir string) (*LastCommand, error) {
+	command, err := os.ReadFile(filepath.Join(dir, "command"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoLastCommand
+		}
+		return nil, fmt.Errorf("error reading last command: %w", err)
+	}
+
+	last := &LastCommand{Command: strings.TrimRight(string(command), "\n")}
+	if last.Command == "" {
+		return nil, ErrNoLastCommand
+	}
+
+	if status, err := os.ReadFile(filepath.Join(dir, "status")); err == nil {
+		last.ExitCode, _ = strconv.Atoi(strings.TrimSpace(string(status)))
+	}
+
+	if cwd, err := os.ReadFile(filepath.Join(dir, "cwd")); err == nil {
+		last.Cwd = strings.TrimSpace(string(cwd))
+	}
+
+	if stderr, err := os.ReadFile(filepath.Join(dir, "stderr")); err == nil {
+		lines := strings.Split(strings.TrimRight(string(stderr), "\n"), "\n")
+		if len(lines) > maxStderrLines {
+			lines = lines[len(lines)-maxStderrLines:]
+		}
+		last.Stderr = strings.TrimSpace(strings.Join(lines, "\n"))
+	}
+
+	return last, nil
+}
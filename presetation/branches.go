@@ -0,0 +1,289 @@
+package presetation
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+// The conversation is a tree of messages linked by their parent. The messages of the current branch,
+// from the first prompt to the last answer, are m.messages and are the ones sent to the model. The
+// messages of the other branches are kept in m.branches, so they can be switched back to. Notices are
+// only displayed, they are not part of the tree
+
+// linkMessages gives an ID and a time to the new messages of the current branch and links each one to the previous
+func (m *ChatModel) linkMessages() {
+	now := time.Now()
+	parent := ""
+	for idx := range m.messages {
+		if m.messages[idx].Role == domain.RoleNotice {
+			continue
+		}
+
+		if m.messages[idx].ID == "" {
+			m.messages[idx].ID = uuid.NewString()
+		}
+		if m.messages[idx].CreatedAt.IsZero() {
+			m.messages[idx].CreatedAt = now
+		}
+
+		m.messages[idx].ParentID = parent
+		parent = m.messages[idx].ID
+	}
+}
+
+// fork moves the messages from the given one on to the other branches, so the conversation continues
+// from the message before it in a new branch
+func (m *ChatModel) fork(at int) {
+	m.linkMessages()
+
+	m.branches = append(m.branches, withoutNotices(m.messages[at:])...)
+	m.messages = slices.Clone(m.messages[:at])
+}
+
+// siblings counts the branches starting at each message, by the ID of their parent, and returns the
+// position of each message among its siblings
+func siblings(messages, branches []domain.Message) (map[string]int, map[string]int) {
+	counts := map[string]int{}
+	positions := map[string]int{}
+
+	// Messages are numbered in the order they were written
+	all := append(slices.Clone(branches), messages...)
+	slices.SortStableFunc(all, func(a, b domain.Message) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	for _, msg := range all {
+		if msg.ID == "" {
+			continue
+		}
+
+		counts[msg.ParentID]++
+		positions[msg.ID] = counts[msg.ParentID]
+	}
+
+	return counts, positions
+}
+
+// leaves returns the last message of every branch, oldest first
+func (m *ChatModel) leaves() []domain.Message {
+	m.linkMessages()
+
+	all := append(slices.Clone(m.branches), withoutNotices(m.messages)...)
+	parents := map[string]bool{}
+	for _, msg := range all {
+		parents[msg.ParentID] = true
+	}
+
+	var leaves []domain.Message
+	for _, msg := range all {
+		if !parents[msg.ID] {
+			leaves = append(leaves, msg)
+		}
+	}
+
+	slices.SortStableFunc(leaves, func(a, b domain.Message) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return leaves
+}
+
+// branchPath returns the messages from the first prompt to the leaf
+func (m *ChatModel) branchPath(leaf domain.Message) []domain.Message {
+	byID := map[string]domain.Message{}
+	for _, msg := range append(slices.Clone(m.branches), withoutNotices(m.messages)...) {
+		byID[msg.ID] = msg
+	}
+
+	path := []domain.Message{leaf}
+	for parent, ok := byID[leaf.ParentID]; ok; parent, ok = byID[parent.ParentID] {
+		path = append(path, parent)
+	}
+
+	slices.Reverse(path)
+	return path
+}
+
+// switchBranch makes the branch ending at the leaf the current one
+func (m *ChatModel) switchBranch(leaf domain.Message) {
+	path := m.branchPath(leaf)
+
+	onPath := map[string]bool{}
+	for _, msg := range path {
+		onPath[msg.ID] = true
+	}
+
+	var rest []domain.Message
+	for _, msg := range append(slices.Clone(m.branches), withoutNotices(m.messages)...) {
+		if !onPath[msg.ID] {
+			rest = append(rest, msg)
+		}
+	}
+
+	m.messages, m.branches = path, rest
+}
+
+// retryAnswer answers the last prompt again. The previous answer, and the command outputs that came with it,
+// are kept in another branch. A command typed after "run:" is run again
+func (m *ChatModel) retryAnswer() tea.Cmd {
+	prompts := m.userPrompts()
+	if len(prompts) == 0 {
+		return m.branchWarning("There is no prompt to answer again.")
+	}
+
+	last := prompts[len(prompts)-1]
+	if last+1 < len(m.messages) {
+		m.fork(last + 1)
+	}
+
+	if command, ok := strings.CutPrefix(m.messages[last].Content, "run:"); ok {
+		return tea.Batch(m.updateViewportContent(), m.requestCommand(strings.TrimSpace(command), ""))
+	}
+
+	return tea.Batch(m.updateViewportContent(), m.getAIResponse())
+}
+
+// editPrompt puts a prompt of the conversation in the composer. Sending it starts a new branch from that prompt.
+// Prompts are numbered from the first one, without a number the last one is edited
+func (m *ChatModel) editPrompt(arg string) tea.Cmd {
+	prompts := m.userPrompts()
+	if len(prompts) == 0 {
+		return m.branchWarning("There is no prompt to edit.")
+	}
+
+	number := len(prompts)
+	if arg != "" {
+		parsed, err := strconv.Atoi(arg)
+		if err != nil || parsed < 1 || parsed > len(prompts) {
+			return m.branchWarning(fmt.Sprintf("There is no prompt %s, the conversation has %d.", arg, len(prompts)))
+		}
+		number = parsed
+	}
+
+	m.editing = prompts[number-1]
+
+	// The composer is filled once the slash command is cleared from it
+	content := m.messages[m.editing].Content
+	return func() tea.Msg { return editPromptMsg{content: content} }
+}
+
+// cancelEdit leaves the prompt being edited as it was
+func (m *ChatModel) cancelEdit() {
+	m.editing = -1
+	m.setComposerValue("")
+}
+
+// listBranches shows the branches of the conversation, numbered for /branch
+func (m *ChatModel) listBranches() tea.Cmd {
+	leaves := m.leaves()
+	if len(leaves) < 2 {
+		return m.branchWarning("The conversation has a single branch, /retry and /edit start new ones.")
+	}
+
+	conversation := withoutNotices(m.messages)
+	current := conversation[len(conversation)-1].ID
+
+	var sb strings.Builder
+	sb.WriteString("Branches of this conversation, switch with /branch N:\n")
+	for idx, leaf := range leaves {
+		marker := " "
+		if leaf.ID == current {
+			marker = "●"
+		}
+
+		path := m.branchPath(leaf)
+		fmt.Fprintf(&sb, "%s %d. %s · %s\n", marker, idx+1, leaf.CreatedAt.Local().Format("Jan 02 15:04"), branchSummary(path))
+	}
+
+	m.messages = append(m.messages, domain.Message{
+		Role:    domain.RoleNotice,
+		Content: strings.TrimRight(sb.String(), "\n"),
+	})
+	return m.updateViewportContent()
+}
+
+// selectBranch makes the numbered branch of /branches the current one
+func (m *ChatModel) selectBranch(arg string) tea.Cmd {
+	leaves := m.leaves()
+	number, err := strconv.Atoi(arg)
+	if err != nil || number < 1 || number > len(leaves) {
+		return m.branchWarning(fmt.Sprintf("There is no branch %s, /branches lists them.", arg))
+	}
+
+	m.switchBranch(leaves[number-1])
+	m.editing = -1
+	m.saveSession()
+	return m.updateViewportContent()
+}
+
+// branchSummary describes a branch by its last prompt and the start of its last answer
+func branchSummary(path []domain.Message) string {
+	prompt, answer := "", ""
+	for idx := len(path) - 1; idx >= 0 && prompt == ""; idx-- {
+		switch path[idx].Role {
+		case domain.RoleUser:
+			prompt = path[idx].Content
+		case domain.RoleAssistant:
+			if answer == "" {
+				answer = path[idx].Content
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%q", firstLine(prompt, 40))
+	if answer != "" {
+		summary += " → " + firstLine(answer, 40)
+	}
+
+	return summary
+}
+
+func firstLine(text string, limit int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if runes := []rune(line); len(runes) > limit {
+		return string(runes[:limit]) + "…"
+	}
+
+	return line
+}
+
+// userPrompts returns the indexes of the prompts of the current branch
+func (m *ChatModel) userPrompts() []int {
+	var prompts []int
+	for idx, msg := range m.messages {
+		if msg.Role == domain.RoleUser {
+			prompts = append(prompts, idx)
+		}
+	}
+
+	return prompts
+}
+
+// promptNumber returns the number /edit gives to the prompt at the index
+func (m *ChatModel) promptNumber(index int) int {
+	return slices.Index(m.userPrompts(), index) + 1
+}
+
+// withoutNotices returns the messages of the conversation, without the ones only displayed
+func withoutNotices(messages []domain.Message) []domain.Message {
+	return slices.DeleteFunc(slices.Clone(messages), func(msg domain.Message) bool {
+		return msg.Role == domain.RoleNotice
+	})
+}
+
+// branchWarning shows the reason a branch command cannot run
+func (m *ChatModel) branchWarning(content string) tea.Cmd {
+	m.messages = append(m.messages, domain.Message{
+		Role:    domain.RoleNotice,
+		Content: WarningStyle.Render(content),
+	})
+	return m.updateViewportContent()
+}
@@ -0,0 +1,50 @@
+package presetation
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+type commandRequest struct {
+	request domain.CommandRequest
+	reply   chan domain.CommandResult
+}
+
+// CommandBroker hands the commands proposed by the agent over to the TUI confirm flow
+// and waits for the user decision. It implements domain.CommandRunner
+type CommandBroker struct {
+	requests chan commandRequest
+}
+
+// RunCommand blocks until the user approves and runs the command, declines it, or the context is done
+func (b *CommandBroker) RunCommand(ctx context.Context, request domain.CommandRequest) (domain.CommandResult, error) {
+	reply := make(chan domain.CommandResult, 1)
+
+	select {
+	case b.requests <- commandRequest{request: request, reply: reply}:
+	case <-ctx.Done():
+		return domain.CommandResult{}, ctx.Err()
+	}
+
+	select {
+	case result := <-reply:
+		return result, nil
+	case <-ctx.Done():
+		return domain.CommandResult{}, ctx.Err()
+	}
+}
+
+// waitForRequest returns a command that delivers the next request proposed by the agent to the TUI
+func (b *CommandBroker) waitForRequest() tea.Cmd {
+	return func() tea.Msg {
+		req := <-b.requests
+		return CommandRequestMsg{Request: req.request, reply: req.reply}
+	}
+}
+
+func NewCommandBroker() *CommandBroker {
+	return &CommandBroker{requests: make(chan commandRequest)}
+}
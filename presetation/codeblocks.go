@@ -0,0 +1,86 @@
+package presetation
+
+import (
+	"fmt"
+	"strings"
+)
+
+type codeBlock struct {
+	lang string
+	code string
+}
+
+// fence returns the fence opening a code block in the line, or an empty string when there is none
+func fence(line string) string {
+	line = strings.TrimSpace(line)
+
+	for _, char := range []string{"`", "~"} {
+		if !strings.HasPrefix(line, strings.Repeat(char, 3)) {
+			continue
+		}
+
+		length := len(line) - len(strings.TrimLeft(line, char))
+		return line[:length]
+	}
+
+	return ""
+}
+
+// parseCodeBlocks returns the fenced code blocks of a markdown text and the index of the line opening each one.
+// A block that is not closed runs to the end of the text
+func parseCodeBlocks(markdown string) ([]codeBlock, []int) {
+	var (
+		blocks []codeBlock
+		starts []int
+		open   string
+		lines  []string
+	)
+
+	for idx, line := range strings.Split(markdown, "\n") {
+		if open == "" {
+			if open = fence(line); open != "" {
+				starts = append(starts, idx)
+				blocks = append(blocks, codeBlock{lang: strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), open))})
+				lines = nil
+			}
+			continue
+		}
+
+		// The closing fence uses the same character, is at least as long as the opening one and has no info string
+		if closing := fence(line); closing != "" && closing[0] == open[0] && len(closing) >= len(open) && closing == strings.TrimSpace(line) {
+			blocks[len(blocks)-1].code = strings.Join(lines, "\n")
+			open = ""
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if open != "" {
+		blocks[len(blocks)-1].code = strings.Join(lines, "\n")
+	}
+
+	return blocks, starts
+}
+
+// numberCodeBlocks adds a numbered marker before every fenced code block, the number /copy and /run refer to
+func numberCodeBlocks(markdown string) string {
+	_, starts := parseCodeBlocks(markdown)
+	if len(starts) == 0 {
+		return markdown
+	}
+
+	lines := strings.Split(markdown, "\n")
+	numbered := make([]string, 0, len(lines)+3*len(starts))
+
+	next := 0
+	for idx, line := range lines {
+		if next < len(starts) && starts[next] == idx {
+			next++
+			numbered = append(numbered, "", fmt.Sprintf("**[%d]**", next), "")
+		}
+		numbered = append(numbered, line)
+	}
+
+	return strings.Join(numbered, "\n")
+}
@@ -0,0 +1,46 @@
+package presetation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+func formatDryRun(msg DryRunMsg) string {
+	var sb strings.Builder
+
+	if msg.Err != nil {
+		sb.WriteString(ErrorStyle.Render(fmt.Sprintf("Dry-run failed: %v", msg.Err)))
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("Dry-run of %s (isolation: %s, exit code %d)\n", CommandStyle.Render(msg.Command), msg.Result.Isolation, msg.Result.ExitCode))
+
+	if output := strings.TrimSpace(msg.Result.Output); output != "" {
+		sb.WriteString("Output:\n" + output + "\n")
+	}
+
+	if len(msg.Result.Changes) == 0 {
+		sb.WriteString("No files in the working directory would change.")
+		return sb.String()
+	}
+
+	sb.WriteString("Files that would change:\n")
+	for _, change := range msg.Result.Changes {
+		marker := "M"
+		switch change.Kind {
+		case domain.FileAdded:
+			marker = "A"
+		case domain.FileDeleted:
+			marker = "D"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s\n", marker, change.Path))
+	}
+
+	if msg.Result.Diff != "" {
+		sb.WriteString("\n" + msg.Result.Diff)
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,30 @@
+package presetation
+
+import (
+	"errors"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+var errorTitles = map[domain.ErrorKind]string{
+	domain.ErrorAuth:           "Authentication failed",
+	domain.ErrorRateLimit:      "Rate limited",
+	domain.ErrorUnavailable:    "Provider unavailable",
+	domain.ErrorTimeout:        "Request timed out",
+	domain.ErrorContextTooLong: "Conversation too long",
+	domain.ErrorNetwork:        "Network error",
+	domain.ErrorToolFailure:    "Tool failed",
+}
+
+// describeError returns a title for the kind of the error and the hint to solve it, when known
+func describeError(err error) (title, hint string) {
+	var agentErr *domain.AgentError
+	if errors.As(err, &agentErr) {
+		if title, ok := errorTitles[agentErr.Kind]; ok {
+			return title, agentErr.Hint
+		}
+		return "Error", agentErr.Hint
+	}
+
+	return "Error", ""
+}
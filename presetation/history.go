@@ -0,0 +1,121 @@
+package presetation
+
+import (
+	"strings"
+)
+
+// promptHistory recalls the prompts sent before, with Up/Down and with a Ctrl-R reverse search
+type promptHistory struct {
+	entries []string
+	// index is the entry shown in the composer while browsing, len(entries) when writing a new prompt
+	index int
+	// draft is the prompt being written when browsing or searching started, restored when it ends
+	draft string
+
+	searching bool
+	query     string
+	// match is the index of the entry found by the search, -1 when nothing matches
+	match int
+}
+
+func newPromptHistory(entries []string) promptHistory {
+	return promptHistory{entries: entries, index: len(entries), match: -1}
+}
+
+// add appends a sent prompt, skipping repeats of the last one, and stops browsing
+func (h *promptHistory) add(entry string) bool {
+	if strings.TrimSpace(entry) == "" {
+		return false
+	}
+
+	added := len(h.entries) == 0 || h.entries[len(h.entries)-1] != entry
+	if added {
+		h.entries = append(h.entries, entry)
+	}
+
+	h.index = len(h.entries)
+	h.draft = ""
+	return added
+}
+
+// previous returns the prompt sent before the one shown, saving the current text as the draft when browsing starts
+func (h *promptHistory) previous(current string) (string, bool) {
+	if h.index == 0 {
+		return "", false
+	}
+
+	if h.index == len(h.entries) {
+		h.draft = current
+	}
+
+	h.index--
+	return h.entries[h.index], true
+}
+
+// next returns the prompt sent after the one shown, or the draft after the most recent one
+func (h *promptHistory) next() (string, bool) {
+	if h.index >= len(h.entries) {
+		return "", false
+	}
+
+	h.index++
+	if h.index == len(h.entries) {
+		return h.draft, true
+	}
+
+	return h.entries[h.index], true
+}
+
+// startSearch begins a reverse search, saving the current text to restore it if the search is canceled
+func (h *promptHistory) startSearch(current string) {
+	h.searching = true
+	h.query = ""
+	h.match = -1
+	h.draft = current
+}
+
+// search finds the most recent entry before the index from that contains the query
+func (h *promptHistory) search(from int) (string, bool) {
+	for idx := min(from, len(h.entries)) - 1; idx >= 0; idx-- {
+		if strings.Contains(strings.ToLower(h.entries[idx]), strings.ToLower(h.query)) {
+			h.match = idx
+			return h.entries[idx], true
+		}
+	}
+
+	return "", false
+}
+
+// searchOlder moves to the next older match of the query
+func (h *promptHistory) searchOlder() (string, bool) {
+	if h.match < 0 {
+		return h.search(len(h.entries))
+	}
+
+	return h.search(h.match)
+}
+
+// setQuery changes the query and searches again from the most recent entry
+func (h *promptHistory) setQuery(query string) (string, bool) {
+	h.query = query
+	h.match = -1
+	if query == "" {
+		return "", false
+	}
+
+	return h.search(len(h.entries))
+}
+
+// endSearch stops the search. The matched entry becomes the one being browsed when accepted,
+// otherwise the draft is returned to restore the composer
+func (h *promptHistory) endSearch(accept bool) string {
+	h.searching = false
+
+	if accept && h.match >= 0 {
+		h.index = h.match
+		return h.entries[h.match]
+	}
+
+	h.index = len(h.entries)
+	return h.draft
+}
@@ -0,0 +1,182 @@
+package presetation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// KeyMap holds the key bindings of the chat
+type KeyMap struct {
+	Send            key.Binding
+	Newline         key.Binding
+	Cancel          key.Binding
+	Quit            key.Binding
+	HistoryPrevious key.Binding
+	HistoryNext     key.Binding
+	HistorySearch   key.Binding
+	ScrollUp        key.Binding
+	ScrollDown      key.Binding
+	Sessions        key.Binding
+	Help            key.Binding
+
+	// The keys of the session browser
+	SessionUp     key.Binding
+	SessionDown   key.Binding
+	SessionSearch key.Binding
+	SessionRename key.Binding
+	SessionDelete key.Binding
+	ConfirmDelete key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Send:            key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "send")),
+		Newline:         key.NewBinding(key.WithKeys("alt+enter", "ctrl+j"), key.WithHelp("alt+enter", "new line")),
+		Cancel:          key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "stop answer, cancel edit, dismiss error")),
+		Quit:            key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c ×2", "quit")),
+		HistoryPrevious: key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "previous prompt")),
+		HistoryNext:     key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "next prompt")),
+		HistorySearch:   key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "search prompts")),
+		ScrollUp:        key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "scroll up")),
+		ScrollDown:      key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdown", "scroll down")),
+		Sessions:        key.NewBinding(key.WithKeys("ctrl+o"), key.WithHelp("ctrl+o", "browse sessions")),
+		Help:            key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "toggle help")),
+
+		SessionUp:     key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "previous session")),
+		SessionDown:   key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "next session")),
+		SessionSearch: key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search sessions")),
+		SessionRename: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "rename session")),
+		SessionDelete: key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete session")),
+		ConfirmDelete: key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm delete")),
+	}
+}
+
+// keyPresets add the keys familiar to users of each editor to the default bindings
+var keyPresets = map[string]map[string][]string{
+	"emacs": {
+		"cancel":           {"esc", "ctrl+g"},
+		"history_previous": {"up", "ctrl+p"},
+		"history_next":     {"down", "ctrl+n"},
+		"scroll_up":        {"pgup", "alt+v"},
+		"scroll_down":      {"pgdown", "ctrl+v"},
+		"help":             {"?", "f1"},
+	},
+	"vim": {
+		"history_previous": {"up", "ctrl+p"},
+		"history_next":     {"down", "ctrl+n"},
+		"scroll_up":        {"pgup", "ctrl+b"},
+		"scroll_down":      {"pgdown", "ctrl+f"},
+		"help":             {"?", "f1"},
+	},
+}
+
+// NewKeyMap returns the bindings of a preset (default, emacs or vim) with the keys of some actions replaced
+func NewKeyMap(preset string, bindings map[string][]string) (KeyMap, error) {
+	keys := DefaultKeyMap()
+
+	if preset != "" && preset != "default" {
+		presetBindings, ok := keyPresets[preset]
+		if !ok {
+			return KeyMap{}, fmt.Errorf("unknown key preset %q", preset)
+		}
+
+		for action, bound := range presetBindings {
+			keys.bind(action, bound)
+		}
+	}
+
+	for action, bound := range bindings {
+		if keys.binding(action) == nil {
+			return KeyMap{}, fmt.Errorf("unknown key action %q", action)
+		}
+
+		keys.bind(action, bound)
+	}
+
+	return keys, nil
+}
+
+// bind replaces the keys of an action and the keys shown for it in the help
+func (k *KeyMap) bind(action string, keys []string) {
+	binding := k.binding(action)
+	binding.SetKeys(keys...)
+
+	names := make([]string, len(keys))
+	for idx, name := range keys {
+		switch name {
+		case "up":
+			names[idx] = "↑"
+		case "down":
+			names[idx] = "↓"
+		default:
+			names[idx] = name
+		}
+	}
+
+	help := strings.Join(names, "/")
+	if action == "quit" {
+		help += " ×2"
+	}
+	binding.SetHelp(help, binding.Help().Desc)
+}
+
+func (k *KeyMap) binding(action string) *key.Binding {
+	switch action {
+	case "send":
+		return &k.Send
+	case "newline":
+		return &k.Newline
+	case "cancel":
+		return &k.Cancel
+	case "quit":
+		return &k.Quit
+	case "history_previous":
+		return &k.HistoryPrevious
+	case "history_next":
+		return &k.HistoryNext
+	case "history_search":
+		return &k.HistorySearch
+	case "scroll_up":
+		return &k.ScrollUp
+	case "scroll_down":
+		return &k.ScrollDown
+	case "sessions":
+		return &k.Sessions
+	case "help":
+		return &k.Help
+	case "session_up":
+		return &k.SessionUp
+	case "session_down":
+		return &k.SessionDown
+	case "session_search":
+		return &k.SessionSearch
+	case "session_rename":
+		return &k.SessionRename
+	case "session_delete":
+		return &k.SessionDelete
+	case "confirm_delete":
+		return &k.ConfirmDelete
+	}
+
+	return nil
+}
+
+// quitKey returns the keys shown for quitting, without the note that it takes two presses
+func (k KeyMap) quitKey() string {
+	return strings.TrimSuffix(k.Quit.Help().Key, " ×2")
+}
+
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Send, k.Newline, k.Cancel, k.Help}
+}
+
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Send, k.Newline, k.Cancel, k.Quit},
+		{k.HistoryPrevious, k.HistoryNext, k.HistorySearch},
+		{k.ScrollUp, k.ScrollDown, k.Sessions, k.Help},
+		{k.SessionUp, k.SessionDown, k.SessionSearch, k.SessionRename, k.SessionDelete},
+	}
+}
@@ -0,0 +1,204 @@
+package presetation
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// resizeDebounce is how long the window has to keep its size before the answers are rendered again
+// at the new width, so dragging the window does not render the conversation at every step
+const resizeDebounce = 150 * time.Millisecond
+
+// markdownCache renders the answers with glamour at the width of the viewport and keeps the output
+// of each one, so only new answers are rendered when the conversation changes. It is used from the
+// commands that build the viewport content, so it is safe for concurrent use
+type markdownCache struct {
+	mu       sync.Mutex
+	theme    Theme
+	width    int
+	renderer *glamour.TermRenderer
+	rendered map[string]string
+}
+
+func newMarkdownCache(theme Theme, width int) (*markdownCache, error) {
+	c := &markdownCache{}
+	return c, c.configure(theme, width)
+}
+
+// configure rebuilds the renderer when the theme or the width change, dropping the rendered answers
+func (c *markdownCache) configure(theme Theme, width int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.renderer != nil && c.theme == theme && c.width == width {
+		return nil
+	}
+
+	renderer, err := newRenderer(theme, width)
+	if err != nil {
+		return err
+	}
+
+	c.theme, c.width, c.renderer = theme, width, renderer
+	c.rendered = map[string]string{}
+	return nil
+}
+
+// render returns the markdown rendered for the terminal, or as is when it cannot be rendered.
+// Partial answers still being streamed are not kept
+func (c *markdownCache) render(markdown string, keep bool) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if rendered, ok := c.rendered[markdown]; ok {
+		return rendered
+	}
+
+	if c.renderer == nil {
+		return markdown + "\n"
+	}
+
+	rendered, err := c.renderer.Render(markdown)
+	if err != nil {
+		return markdown + "\n"
+	}
+
+	if keep {
+		c.rendered[markdown] = rendered
+	}
+	return rendered
+}
+
+func (c *markdownCache) setTheme(theme Theme) error {
+	c.mu.Lock()
+	width := c.width
+	c.mu.Unlock()
+
+	return c.configure(theme, width)
+}
+
+func (c *markdownCache) setWidth(width int) error {
+	c.mu.Lock()
+	theme := c.theme
+	c.mu.Unlock()
+
+	return c.configure(theme, width)
+}
+
+// markdownWidth is the word wrap of the answers for a viewport width, leaving room for the glamour margins
+func markdownWidth(viewportWidth int) int {
+	return max(viewportWidth-4, 20)
+}
+
+// layout sizes the parts of the chat for the window: the header and the footer take the lines they need,
+// the composer grows with its text and the viewport gets the rest of the height
+func (m *ChatModel) layout() {
+	m.composer.SetHeight(min(max(m.composer.LineCount(), 1), maxComposerHeight))
+
+	if !m.ready {
+		return
+	}
+
+	// The prompt in front of the composer holds the spinner or the confirm label
+	m.composer.SetWidth(max(m.width-lipgloss.Width(m.promptText())-2, 10))
+
+	atBottom := m.viewport.AtBottom()
+
+	m.viewport.Width = max(m.width-m.sidebarWidth(), 1)
+	// View puts a blank line between the viewport and the header and footer
+	m.viewport.Height = max(m.height-lipgloss.Height(m.header())-lipgloss.Height(m.footer())-2, 1)
+
+	if atBottom {
+		m.viewport.GotoBottom()
+	}
+}
+
+// relayout lays the chat out and renders the conversation again at the width of the viewport
+func (m *ChatModel) relayout() tea.Cmd {
+	m.layout()
+
+	if err := m.renderer.setWidth(markdownWidth(m.viewport.Width)); err != nil {
+		m.showError(err)
+	}
+	return m.updateViewportContent()
+}
+
+// header returns the title and the error banner shown above the conversation
+func (m *ChatModel) header() string {
+	lines := []string{
+		TitleStyle.Render("How - Terminal AI Assistant"),
+		InfoStyle.Render("Type your question to get an answer or request help to How assistant"),
+	}
+
+	if m.error != "" {
+		lines = append(lines, "", ErrorStyle.Render(m.error)+StatusStyle.Render(fmt.Sprintf("(%s to dismiss)", m.keys.Cancel.Help().Key)))
+		if m.errorHint != "" {
+			lines = append(lines, InfoStyle.Render("Hint: "+m.errorHint))
+		}
+	}
+
+	return m.wrap(strings.Join(lines, "\n"))
+}
+
+// wrap breaks the lines longer than the window, so they take the height they are measured with
+func (m *ChatModel) wrap(s string) string {
+	if m.width <= 0 {
+		return s
+	}
+
+	return ansi.Wrap(s, m.width, "")
+}
+
+// promptText returns what is shown in front of the composer
+func (m *ChatModel) promptText() string {
+	if m.confirmMode {
+		return ConfirmStyle.Render("Confirm") + " "
+	}
+
+	if m.waitingForAI {
+		return m.spinner.View() + " "
+	}
+
+	return ""
+}
+
+// footer returns the confirmation details, the composer and the status bar shown below the conversation
+func (m *ChatModel) footer() string {
+	var s strings.Builder
+
+	if m.confirmMode {
+		if m.pendingPolicy.Reason != "" && m.pendingPolicy.Rule != "" {
+			s.WriteString(WarningStyle.Render(fmt.Sprintf("Policy: %s (%s)", m.pendingPolicy.Reason, m.pendingPolicy.Rule)) + "\n\n")
+		}
+
+		if len(m.pendingRisk.Findings) > 0 {
+			s.WriteString(ConfirmStyle.Render(fmt.Sprintf("Risk: %s", m.pendingRisk.Level)) + "\n")
+			for _, finding := range m.pendingRisk.Findings {
+				s.WriteString(WarningStyle.Render(fmt.Sprintf("• [%s] %s: %s", finding.Category, finding.Command, finding.Reason)) + "\n")
+			}
+			s.WriteString("\n")
+		}
+	}
+
+	s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, PromptStyle.Render(m.promptText()), m.composer.View()) + "\n")
+
+	if m.history.searching {
+		s.WriteString(InfoStyle.Render(fmt.Sprintf(
+			"(reverse-i-search)`%s': %s to accept, %s to cancel",
+			m.history.query,
+			m.keys.Send.Help().Key,
+			m.keys.Cancel.Help().Key,
+		)))
+	} else {
+		s.WriteString(m.statusBar())
+	}
+
+	return m.wrap(s.String())
+}
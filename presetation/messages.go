@@ -1,8 +1,84 @@
 package presetation
 
-type (
-	AIResponseMsg      string
-	CommandOutputMsg   string
-	ErrorMsg           string
-	ViewportContentMsg string
+import (
+	"github.com/antunesgabriel/how/domain"
 )
+
+// AIChunkMsg carries the next part of the answer being streamed for a request
+type AIChunkMsg struct {
+	Content string
+	request int
+	stream  domain.StreamResponse
+}
+
+// AIResponseMsg is sent when the answer of a request is complete
+type AIResponseMsg struct {
+	Usage   *domain.Usage
+	request int
+}
+
+// AIErrorMsg is sent when a request fails
+type AIErrorMsg struct {
+	Err     error
+	request int
+}
+
+type quitHintMsg struct{}
+
+// relayoutMsg is sent once the window stops being resized
+type relayoutMsg struct {
+	resize int
+}
+
+// editPromptMsg fills the composer with the prompt picked by /edit, after the command is cleared from it
+type editPromptMsg struct {
+	content string
+}
+
+type CommandOutputMsg string
+
+// clipboardMsg is sent once /copy has copied a code block
+type clipboardMsg struct {
+	number int
+	err    error
+}
+
+// SessionSummaryMsg carries the title and tags written for a session
+type SessionSummaryMsg struct {
+	Summary   domain.SessionSummary
+	Err       error
+	sessionID string
+}
+
+// SearchResultsMsg carries the results of a search over the saved sessions
+type SearchResultsMsg struct {
+	Results []domain.SearchResult
+	Err     error
+	query   string
+}
+
+// SessionsMsg carries the saved sessions listed by the session browser
+type SessionsMsg struct {
+	Sessions []domain.Session
+	Err      error
+}
+
+// ViewportContentMsg carries the rendered conversation. The content is built in the background, so only
+// the latest version is shown when several are in flight
+type ViewportContentMsg struct {
+	Content string
+	version int
+	// offsets are the lines where each message starts in the content
+	offsets []int
+}
+
+type DryRunMsg struct {
+	Command string
+	Result  domain.DryRunResult
+	Err     error
+}
+
+type CommandRequestMsg struct {
+	Request domain.CommandRequest
+	reply   chan domain.CommandResult
+}
@@ -2,70 +2,172 @@ package presetation
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"os/user"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/google/uuid"
 
 	"github.com/antunesgabriel/how/domain"
 )
 
+const welcomeMessage = "Welcome to Terminal AI Chat! Type a message and press Enter to chat with the AI."
+
+// maxComposerHeight is the number of lines the composer grows to before scrolling
+const maxComposerHeight = 6
+
+// quitConfirmWindow is how long a second Ctrl-C has to be pressed within to quit
+const quitConfirmWindow = 2 * time.Second
+
 type ChatModel struct {
-	textInput      textinput.Model
+	composer       textarea.Model
+	keys           KeyMap
+	help           help.Model
+	showHelp       bool
+	history        promptHistory
+	historyStore   domain.PromptHistory
 	messages       []domain.Message
 	viewport       viewport.Model
 	spinner        spinner.Model
 	agent          domain.Agent
-	renderer       *glamour.TermRenderer
+	analyzer       domain.CommandAnalyzer
+	policy         domain.CommandPolicy
+	auditLogger    domain.AuditLogger
+	sandbox        domain.Sandbox
+	clipboard      domain.Clipboard
+	terminal       *Terminal
+	broker         *CommandBroker
+	prompts        domain.PromptLibrary
+	promptName     string
+	pendingReply   chan domain.CommandResult
+	pendingReason  string
+	sessionID      string
+	sessionTitle   string
+	sessionTags    []string
+	titler         domain.SessionTitler
+	titling        bool
+	titleUsage     []domain.Usage
+	searcher       domain.Searcher
+	focusMessage   int
+	branches       []domain.Message
+	editing        int
+	sessions       sessionBrowser
+	sessionStore   domain.SessionStore
+	startedAt      time.Time
+	usage          domain.Usage
+	provider       string
+	model          string
+	answeredBy     string
+	renderer       *markdownCache
+	resize         int
+	contentVersion int
 	waitingForAI   bool
+	request        int
+	cancelRequest  context.CancelFunc
+	stream         domain.StreamResponse
+	streaming      string
+	quitPressedAt  time.Time
 	pendingCommand string
+	pendingRisk    domain.RiskReport
+	pendingPolicy  domain.PolicyDecision
 	confirmMode    bool
 	error          string
+	errorHint      string
 	ready          bool
 	width          int
 	height         int
 	initialQuery   string
+
+	// pendingSuggestedBy is the answer the pending command was taken from, empty when the user typed it
+	pendingSuggestedBy string
 }
 
 func NewChatModel(agent domain.Agent) *ChatModel {
-	ti := textinput.New()
-	ti.Placeholder = "Ask a question or type a command with 'run:' prefix..."
-	ti.Focus()
-	ti.CharLimit = 500
-	ti.Width = 80
+	ta := textarea.New()
+	ta.Placeholder = "Ask a question or type a command with 'run:' prefix..."
+	ta.Focus()
+	ta.CharLimit = 0
+	ta.MaxHeight = 0
+	ta.ShowLineNumbers = false
+	ta.FocusedStyle.CursorLine = lipgloss.NewStyle()
+	ta.SetPromptFunc(2, func(line int) string {
+		if line == 0 {
+			return "> "
+		}
+		return "  "
+	})
+	ta.SetWidth(80)
+	ta.SetHeight(1)
 
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = SpinnerStyle
 
-	renderer, _ := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
-		glamour.WithWordWrap(80),
-	)
+	renderer, _ := newMarkdownCache(Theme{}, markdownWidth(80))
 
-	initialMessages := []domain.Message{
-		{
-			Role:    domain.RoleSystem,
-			Content: "Welcome to Terminal AI Chat! Type a message and press Enter to chat with the AI.",
-		},
-	}
-
-	return &ChatModel{
-		textInput:    ti,
-		messages:     initialMessages,
+	m := &ChatModel{
+		composer:     ta,
+		help:         help.New(),
+		history:      newPromptHistory(nil),
+		sessions:     newSessionBrowser(),
+		focusMessage: -1,
+		editing:      -1,
 		spinner:      s,
 		agent:        agent,
+		sessionID:    uuid.NewString(),
+		startedAt:    time.Now(),
 		renderer:     renderer,
 		waitingForAI: false,
 		confirmMode:  false,
 		error:        "",
 		ready:        false,
 	}
+	m.setKeyMap(DefaultKeyMap())
+
+	return m
+}
+
+// setKeyMap changes the key bindings of the chat and of the composer and viewport inside it
+func (m *ChatModel) setKeyMap(keys KeyMap) {
+	m.keys = keys
+	m.composer.KeyMap = textarea.DefaultKeyMap
+	m.composer.KeyMap.InsertNewline = keys.Newline
+
+	// Scroll keys reach the composer too, so the presets' ctrl+v, ctrl+f and ctrl+b no longer edit the prompt
+	scrollKeys := append(keys.ScrollUp.Keys(), keys.ScrollDown.Keys()...)
+	for _, binding := range []*key.Binding{
+		&m.composer.KeyMap.CharacterBackward, &m.composer.KeyMap.CharacterForward,
+		&m.composer.KeyMap.DeleteAfterCursor, &m.composer.KeyMap.DeleteBeforeCursor,
+		&m.composer.KeyMap.DeleteCharacterBackward, &m.composer.KeyMap.DeleteCharacterForward,
+		&m.composer.KeyMap.DeleteWordBackward, &m.composer.KeyMap.DeleteWordForward,
+		&m.composer.KeyMap.LineEnd, &m.composer.KeyMap.LineNext, &m.composer.KeyMap.LinePrevious,
+		&m.composer.KeyMap.LineStart, &m.composer.KeyMap.Paste,
+		&m.composer.KeyMap.WordBackward, &m.composer.KeyMap.WordForward,
+		&m.composer.KeyMap.InputBegin, &m.composer.KeyMap.InputEnd,
+		&m.composer.KeyMap.UppercaseWordForward, &m.composer.KeyMap.LowercaseWordForward,
+		&m.composer.KeyMap.CapitalizeWordForward, &m.composer.KeyMap.TransposeCharacterBackward,
+	} {
+		binding.SetKeys(slices.DeleteFunc(slices.Clone(binding.Keys()), func(name string) bool {
+			return slices.Contains(scrollKeys, name)
+		})...)
+	}
+
+	// The viewport only scrolls with the scroll keys, its default keys would also fire while typing
+	m.viewport.KeyMap = viewport.KeyMap{PageUp: keys.ScrollUp, PageDown: keys.ScrollDown}
 }
 
 func (m *ChatModel) SetInitialQuery(query string) {
@@ -73,7 +175,11 @@ func (m *ChatModel) SetInitialQuery(query string) {
 }
 
 func (m *ChatModel) Init() tea.Cmd {
-	cmds := []tea.Cmd{textinput.Blink, m.spinner.Tick, m.updateViewportContent()}
+	cmds := []tea.Cmd{textarea.Blink, m.spinner.Tick, m.updateViewportContent()}
+
+	if m.broker != nil {
+		cmds = append(cmds, m.broker.waitForRequest())
+	}
 
 	if m.initialQuery != "" {
 		m.messages = append(m.messages, domain.Message{
@@ -82,13 +188,19 @@ func (m *ChatModel) Init() tea.Cmd {
 		})
 
 		cmds = append(cmds, m.getAIResponse())
-		m.waitingForAI = true
 	}
 
 	return tea.Batch(cmds...)
 }
 
+// Update handles the message and then lays the chat out again, as most messages change the size of its parts
 func (m *ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	m.layout()
+	return model, cmd
+}
+
+func (m *ChatModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
 		tiCmd tea.Cmd
 		vpCmd tea.Cmd
@@ -98,20 +210,99 @@ func (m *ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlC, tea.KeyEsc:
-			return m, tea.Quit
-		case tea.KeyEnter:
+		if m.history.searching && !key.Matches(msg, m.keys.Quit) {
+			return m, m.updateSearch(msg)
+		}
+
+		if m.sessions.open && !key.Matches(msg, m.keys.Quit) {
+			return m, m.updateSessions(msg)
+		}
+
+		if m.showHelp && !key.Matches(msg, m.keys.Quit) {
+			if key.Matches(msg, m.keys.Help, m.keys.Cancel, m.keys.Send) {
+				m.showHelp = false
+			}
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			if time.Since(m.quitPressedAt) < quitConfirmWindow {
+				return m, tea.Quit
+			}
+
+			m.quitPressedAt = time.Now()
+			m.interruptAIResponse()
+			return m, tea.Batch(
+				m.updateViewportContent(),
+				tea.Tick(quitConfirmWindow, func(time.Time) tea.Msg { return quitHintMsg{} }),
+			)
+		case key.Matches(msg, m.keys.Cancel):
+			if m.waitingForAI {
+				m.interruptAIResponse()
+				return m, m.updateViewportContent()
+			}
+
+			if m.editing >= 0 {
+				m.cancelEdit()
+				return m, nil
+			}
+
+			m.error, m.errorHint = "", ""
+			return m, nil
+		case key.Matches(msg, m.keys.Sessions):
+			return m, m.toggleSessions()
+		case key.Matches(msg, m.keys.Help) && m.composer.Value() == "":
+			m.showHelp = true
+			return m, nil
+		case key.Matches(msg, m.keys.HistoryPrevious):
+			if m.confirmMode || m.composer.Line() > 0 {
+				break
+			}
+
+			if recall, ok := m.history.previous(m.composer.Value()); ok {
+				m.setComposerValue(recall)
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.HistoryNext):
+			if m.confirmMode || m.composer.Line() < m.composer.LineCount()-1 {
+				break
+			}
+
+			if recall, ok := m.history.next(); ok {
+				m.setComposerValue(recall)
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.HistorySearch):
+			if !m.confirmMode {
+				m.history.startSearch(m.composer.Value())
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.Send):
 			if m.confirmMode {
-				input := strings.ToLower(m.textInput.Value())
+				input := strings.ToLower(strings.TrimSpace(m.composer.Value()))
 				m.confirmMode = false
-				m.textInput.SetValue("")
-				m.textInput.Placeholder = "Ask a question or type a command with 'run:' prefix..."
+				m.composer.SetValue("")
+				m.composer.Placeholder = "Ask a question or type a command with 'run:' prefix..."
+
+				confirmed := input == "y" || input == "yes"
+				if m.pendingRisk.Level >= domain.RiskHigh {
+					confirmed = input == "yes"
+				}
 
-				if input == "y" || input == "yes" {
+				if confirmed {
 					return m, m.executeCommand(m.pendingCommand)
 				}
 
+				if m.sandbox != nil && (input == "d" || input == "dry") {
+					m.messages = append(m.messages, domain.Message{
+						Role:    domain.RoleNotice,
+						Content: fmt.Sprintf("Running %s in a sandbox...", CommandStyle.Render(m.pendingCommand)),
+					})
+					return m, tea.Batch(m.updateViewportContent(), m.dryRunCommand(m.pendingCommand))
+				}
+
+				m.replyToAgent(domain.CommandResult{Reason: "the user declined to run the command"})
 				m.messages = append(m.messages, domain.Message{
 					Role:    domain.RoleSystem,
 					Content: "Command execution canceled.",
@@ -119,11 +310,25 @@ func (m *ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.updateViewportContent()
 			}
 
-			input := m.textInput.Value()
-			if input == "" {
+			input := m.composer.Value()
+			if input == "" || m.waitingForAI {
 				return m, nil
 			}
 
+			m.error, m.errorHint = "", ""
+			m.addHistory(input)
+
+			if cmd, ok := m.slashCommand(input); ok {
+				m.setComposerValue("")
+				return m, cmd
+			}
+
+			// An edited prompt replaces the original one and what followed it, which are kept in another branch
+			if m.editing >= 0 {
+				m.fork(m.editing)
+				m.editing = -1
+			}
+
 			m.messages = append(m.messages, domain.Message{
 				Role:    domain.RoleUser,
 				Content: input,
@@ -131,20 +336,11 @@ func (m *ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			if strings.HasPrefix(input, "run:") {
 				command := strings.TrimSpace(strings.TrimPrefix(input, "run:"))
-				m.pendingCommand = command
-				m.confirmMode = true
-				m.textInput.SetValue("")
-				m.textInput.Placeholder = "Execute command? (y/n)"
-
-				m.messages = append(m.messages, domain.Message{
-					Role:    domain.RoleSystem,
-					Content: fmt.Sprintf("Do you want to execute: %s", CommandStyle.Render(command)),
-				})
-				return m, m.updateViewportContent()
+				m.setComposerValue("")
+				return m, m.requestCommand(command, "")
 			}
 
-			m.textInput.SetValue("")
-			m.waitingForAI = true
+			m.setComposerValue("")
 
 			cmds = append(cmds, m.updateViewportContent())
 			cmds = append(cmds, m.getAIResponse())
@@ -153,46 +349,148 @@ func (m *ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.WindowSizeMsg:
-		headerHeight := 6
-		footerHeight := 3
-		verticalMarginHeight := headerHeight + footerHeight
+		m.width = msg.Width
+		m.height = msg.Height
 
 		if !m.ready {
-			m.width = msg.Width
-			m.height = msg.Height
-			m.viewport = viewport.New(msg.Width, msg.Height-verticalMarginHeight)
-			m.viewport.SetContent("")
+			m.viewport = viewport.New(msg.Width, msg.Height)
+			m.setKeyMap(m.keys)
 			m.ready = true
+			return m, m.relayout()
+		}
 
-			m.textInput.Width = msg.Width - 4
-		} else {
-			m.width = msg.Width
-			m.height = msg.Height
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - verticalMarginHeight
-			m.textInput.Width = msg.Width - 4
+		// The answers are rendered again at the new width once the window stops changing
+		m.resize++
+		resize := m.resize
+		return m, tea.Tick(resizeDebounce, func(time.Time) tea.Msg {
+			return relayoutMsg{resize: resize}
+		})
+
+	case editPromptMsg:
+		m.setComposerValue(msg.content)
+		return m, nil
+
+	case relayoutMsg:
+		if msg.resize != m.resize {
+			return m, nil
 		}
 
-		return m, m.updateViewportContent()
+		return m, m.relayout()
+
+	case SessionSummaryMsg:
+		m.titling = false
+
+		// The tokens are counted in the session even when the title is not used
+		if msg.Summary.Usage != nil {
+			m.addTitleUsage(msg.sessionID, *msg.Summary.Usage)
+		}
+
+		// The summary is dropped when another session was opened or the user gave this one a title meanwhile
+		if msg.Err != nil || msg.sessionID != m.sessionID || m.sessionTitle != "" || msg.Summary.Title == "" {
+			return m, nil
+		}
+
+		m.sessionTitle = msg.Summary.Title
+		m.sessionTags = msg.Summary.Tags
+		m.saveSession()
+		return m, nil
+
+	case SearchResultsMsg:
+		if msg.Err != nil {
+			m.error, m.errorHint = fmt.Sprintf("Error: could not search the sessions: %v", msg.Err), ""
+			return m, nil
+		}
+
+		m.sessions.setResults(msg.query, msg.Results)
+		return m, nil
+
+	case SessionsMsg:
+		if msg.Err != nil {
+			m.error, m.errorHint = fmt.Sprintf("Error: could not list the sessions: %v", msg.Err), ""
+			return m, nil
+		}
+
+		m.sessions.setSessions(msg.Sessions)
+
+		// The results are searched again, the sessions they come from may have changed
+		if m.sessions.showsResults() {
+			return m, m.searchSessions(m.sessions.query)
+		}
+		return m, nil
+
+	case AIChunkMsg:
+		if msg.request != m.request {
+			return m, nil
+		}
+
+		m.stream = msg.stream
+		m.streaming += msg.Content
+		return m, tea.Batch(m.updateViewportContent(), readAIResponse(msg.request, msg.stream))
 
 	case AIResponseMsg:
-		m.waitingForAI = false
-		m.messages = append(m.messages, domain.Message{
-			Role:    domain.RoleAssistant,
-			Content: string(msg),
-		})
+		if msg.request != m.request {
+			return m, nil
+		}
+
+		m.finishAIResponse(false, msg.Usage)
+		return m, tea.Batch(m.updateViewportContent(), m.titleSession())
+
+	case AIErrorMsg:
+		if msg.request != m.request {
+			return m, nil
+		}
+
+		m.finishAIResponse(false, nil)
+		m.showError(msg.Err)
 		return m, m.updateViewportContent()
 
+	case quitHintMsg:
+		return m, nil
+
 	case CommandOutputMsg:
 		m.messages = append(m.messages, domain.Message{
 			Role:    domain.RoleSystem,
 			Content: string(msg),
 		})
+		m.saveSession()
 		return m, m.updateViewportContent()
 
-	case ErrorMsg:
-		m.waitingForAI = false
-		m.error = string(msg)
+	case CommandRequestMsg:
+		if m.confirmMode {
+			msg.reply <- domain.CommandResult{Reason: "another command is waiting for the user confirmation, try again later"}
+			return m, m.broker.waitForRequest()
+		}
+
+		m.pendingReply = msg.reply
+		m.pendingReason = msg.Request.Reason
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: fmt.Sprintf("How wants to run %s\nReason: %s", CommandStyle.Render(msg.Request.Command), msg.Request.Reason),
+		})
+
+		return m, tea.Batch(m.requestCommand(msg.Request.Command, ""), m.broker.waitForRequest())
+
+	case clipboardMsg:
+		content := fmt.Sprintf("Copied code block %d to the clipboard.", msg.number)
+		if msg.err != nil {
+			content = ErrorStyle.Render(msg.err.Error())
+		}
+
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: content,
+		})
+		return m, m.updateViewportContent()
+
+	case DryRunMsg:
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: formatDryRun(msg),
+		})
+
+		if msg.Command == m.pendingCommand {
+			m.enterConfirmMode()
+		}
 		return m, m.updateViewportContent()
 
 	case spinner.TickMsg:
@@ -201,12 +499,24 @@ func (m *ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case ViewportContentMsg:
-		m.viewport.SetContent(string(msg))
+		if msg.version != m.contentVersion {
+			return m, nil
+		}
+
+		m.viewport.SetContent(msg.Content)
+
+		// A session opened at a message shows it at the top, once the viewport has its size
+		if m.ready && m.focusMessage >= 0 && m.focusMessage < len(msg.offsets) {
+			m.viewport.SetYOffset(msg.offsets[m.focusMessage])
+			m.focusMessage = -1
+			return m, nil
+		}
+
 		m.viewport.GotoBottom()
 		return m, nil
 	}
 
-	m.textInput, tiCmd = m.textInput.Update(msg)
+	m.composer, tiCmd = m.composer.Update(msg)
 	m.viewport, vpCmd = m.viewport.Update(msg)
 	m.spinner, spCmd = m.spinner.Update(msg)
 
@@ -219,69 +529,700 @@ func (m *ChatModel) View() string {
 		return "Initializing..."
 	}
 
-	s := TitleStyle.Render("How - Terminal AI Assistant") + "\n"
-	s += InfoStyle.Render("Type your question to get an answer or request help to How assistant") + "\n\n"
-
-	if m.error != "" {
-		s += ErrorStyle.Render("Error: "+m.error) + "\n\n"
+	body := m.viewport.View()
+	if m.showHelp {
+		m.help.ShowAll = true
+		overlay := HelpStyle.Render(TitleStyle.UnsetMarginLeft().Render("Keys") + "\n\n" + m.help.View(m.keys))
+		body = lipgloss.Place(m.viewport.Width, m.viewport.Height, lipgloss.Center, lipgloss.Center, overlay)
 	}
 
-	s += m.viewport.View() + "\n\n"
-
-	promptText := ""
-	if m.waitingForAI {
-		promptText = m.spinner.View() + " "
-	} else if m.confirmMode {
-		promptText = ConfirmStyle.Render("Confirm") + " "
+	if m.sessions.open {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, m.sidebarView(m.sidebarWidth(), m.viewport.Height), body)
 	}
 
-	s += PromptStyle.Render(promptText) + m.textInput.View()
-	return s
+	return m.header() + "\n\n" + body + "\n\n" + m.footer()
 }
 
+// getAIResponse starts streaming the answer to the conversation. Every request gets its own context,
+// so it can be canceled without quitting, and a number, so the messages of a canceled request are dropped
 func (m *ChatModel) getAIResponse() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.request++
+	m.cancelRequest = cancel
+	m.stream = nil
+	m.streaming = ""
+	m.waitingForAI = true
+
+	request := m.request
+	messages := slices.Clone(m.messages)
+
 	return func() tea.Msg {
-		response, err := m.agent.GetResponse(context.Background(), m.messages)
+		stream, err := m.agent.GetStreamResponse(ctx, messages)
 		if err != nil {
-			return ErrorMsg(fmt.Sprintf("Error: %v", err))
+			return AIErrorMsg{Err: err, request: request}
 		}
 
-		return AIResponseMsg(response)
+		return readAIResponse(request, stream)()
 	}
 }
 
+// readAIResponse returns a command that waits for the next chunk of the answer
+func readAIResponse(request int, stream domain.StreamResponse) tea.Cmd {
+	return func() tea.Msg {
+		content, done, err := stream.Content()
+		if err != nil {
+			return AIErrorMsg{Err: err, request: request}
+		}
+
+		if done {
+			return AIResponseMsg{Usage: stream.Usage(), request: request}
+		}
+
+		return AIChunkMsg{Content: content, request: request, stream: stream}
+	}
+}
+
+// interruptAIResponse cancels the request in progress, keeping the part of the answer received so far
+func (m *ChatModel) interruptAIResponse() {
+	if !m.waitingForAI {
+		return
+	}
+
+	var usage *domain.Usage
+	if m.stream != nil {
+		usage = m.stream.Usage()
+	}
+
+	m.cancelRequest()
+
+	// A command proposed by the agent can no longer be answered, the tool call was canceled with the request
+	if m.pendingReply != nil {
+		m.pendingReply = nil
+		m.pendingReason = ""
+		m.confirmMode = false
+		m.setComposerValue("")
+		m.composer.Placeholder = "Ask a question or type a command with 'run:' prefix..."
+	}
+
+	if m.streaming == "" {
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: WarningStyle.Render("Request canceled."),
+		})
+	}
+
+	m.finishAIResponse(true, usage)
+}
+
+// showError displays a failed request above the conversation until it is dismissed, and keeps it
+// in the conversation. Errors are never sent to the model
+func (m *ChatModel) showError(err error) {
+	title, hint := describeError(err)
+
+	m.error = fmt.Sprintf("%s: %v", title, err)
+	m.errorHint = hint
+
+	content := m.error
+	if hint != "" {
+		content += "\nHint: " + hint
+	}
+
+	m.messages = append(m.messages, domain.Message{
+		Role:      domain.RoleError,
+		Content:   content,
+		CreatedAt: time.Now(),
+	})
+	m.saveSession()
+}
+
+// finishAIResponse stores the streamed answer, if any, and gets ready for the next request
+func (m *ChatModel) finishAIResponse(interrupted bool, usage *domain.Usage) {
+	m.waitingForAI = false
+	m.request++
+	if m.cancelRequest != nil {
+		m.cancelRequest()
+		m.cancelRequest = nil
+	}
+
+	if m.streaming != "" {
+		m.messages = append(m.messages, domain.Message{
+			Role:        domain.RoleAssistant,
+			Content:     m.streaming,
+			CreatedAt:   time.Now(),
+			Usage:       usage,
+			Interrupted: interrupted,
+		})
+	}
+
+	m.stream = nil
+	m.streaming = ""
+
+	m.addUsage(usage)
+	m.noteProvider(usage)
+	m.saveSession()
+}
+
+// setComposerValue replaces the text of the composer, with the cursor at its end
+func (m *ChatModel) setComposerValue(value string) {
+	m.composer.SetValue(value)
+}
+
+// addHistory records a sent prompt in the history, and in the history store so it is recalled in later sessions
+func (m *ChatModel) addHistory(input string) {
+	if !m.history.add(input) || m.historyStore == nil {
+		return
+	}
+
+	if err := m.historyStore.Append(input); err != nil {
+		m.error = fmt.Sprintf("Error: could not save the prompt history: %v", err)
+		m.errorHint = ""
+	}
+}
+
+// updateSearch handles the keys of the reverse search over the history. The composer shows the match
+// while typing, the send key keeps it to be edited and sent and the cancel key restores the text written before
+func (m *ChatModel) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.setComposerValue(m.history.endSearch(false))
+		return nil
+	case key.Matches(msg, m.keys.Send), msg.Type == tea.KeyTab, msg.Type == tea.KeyLeft, msg.Type == tea.KeyRight:
+		m.setComposerValue(m.history.endSearch(true))
+		return nil
+	case key.Matches(msg, m.keys.HistorySearch):
+		if match, ok := m.history.searchOlder(); ok {
+			m.setComposerValue(match)
+		}
+		return nil
+	case msg.Type == tea.KeyBackspace:
+		query := []rune(m.history.query)
+		if len(query) == 0 {
+			return nil
+		}
+		m.history.setQuery(string(query[:len(query)-1]))
+	case msg.Type == tea.KeyRunes, msg.Type == tea.KeySpace:
+		m.history.setQuery(m.history.query + string(msg.Runes))
+	default:
+		return nil
+	}
+
+	if m.history.match >= 0 {
+		m.setComposerValue(m.history.entries[m.history.match])
+	} else {
+		m.setComposerValue(m.history.draft)
+	}
+
+	return nil
+}
+
+// slashCommand runs the chat commands /system, /copy, /run, /search, /retry, /edit, /branches and /branch.
+// It reports false when the input is not one of them
+func (m *ChatModel) slashCommand(input string) (tea.Cmd, bool) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(input), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/system":
+		return m.selectSystemPrompt(arg), true
+	case "/copy":
+		return m.copyCodeBlock(arg), true
+	case "/run":
+		return m.runCodeBlock(arg), true
+	case "/search":
+		return m.searchFromChat(arg), true
+	case "/retry":
+		return m.retryAnswer(), true
+	case "/edit":
+		return m.editPrompt(arg), true
+	case "/branches":
+		return m.listBranches(), true
+	case "/branch":
+		return m.selectBranch(arg), true
+	}
+
+	return nil, false
+}
+
+// codeBlock returns the code block of the last answer with the number in arg, the first one when arg is empty.
+// When there is no such block the reason is added to the conversation
+func (m *ChatModel) codeBlock(arg string) (codeBlock, int, bool) {
+	blocks, _ := parseCodeBlocks(m.lastAssistantMessage())
+	if len(blocks) == 0 {
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: WarningStyle.Render("The last answer has no code blocks."),
+		})
+		return codeBlock{}, 0, false
+	}
+
+	number := 1
+	if arg != "" {
+		parsed, err := strconv.Atoi(arg)
+		if err != nil || parsed < 1 || parsed > len(blocks) {
+			m.messages = append(m.messages, domain.Message{
+				Role:    domain.RoleNotice,
+				Content: WarningStyle.Render(fmt.Sprintf("There is no code block %s, the last answer has %d.", arg, len(blocks))),
+			})
+			return codeBlock{}, 0, false
+		}
+		number = parsed
+	}
+
+	return blocks[number-1], number, true
+}
+
+// copyCodeBlock copies a code block of the last answer to the clipboard
+func (m *ChatModel) copyCodeBlock(arg string) tea.Cmd {
+	block, number, ok := m.codeBlock(arg)
+	if !ok {
+		return m.updateViewportContent()
+	}
+
+	if m.clipboard == nil {
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: ErrorStyle.Render("No clipboard is available."),
+		})
+		return m.updateViewportContent()
+	}
+
+	// The clipboard may write to the terminal, so it is not used while a frame is drawn
+	clipboard := m.clipboard
+	return func() tea.Msg {
+		return clipboardMsg{number: number, err: clipboard.Copy(block.code)}
+	}
+}
+
+// runCodeBlock sends a code block of the last answer to the confirm flow, as if it was typed after "run:"
+func (m *ChatModel) runCodeBlock(arg string) tea.Cmd {
+	block, _, ok := m.codeBlock(arg)
+	if !ok {
+		return m.updateViewportContent()
+	}
+
+	return m.requestCommand(strings.TrimSpace(block.code), m.lastAssistantMessage())
+}
+
+// selectSystemPrompt switches the agent to the named system prompt, or lists the available prompts when name is empty
+func (m *ChatModel) selectSystemPrompt(name string) tea.Cmd {
+	if m.prompts == nil {
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: ErrorStyle.Render("No system prompts are configured."),
+		})
+		return m.updateViewportContent()
+	}
+
+	if name == "" {
+		var sb strings.Builder
+		sb.WriteString("System prompts (use /system <name> to switch):\n")
+		for _, prompt := range m.prompts.Names() {
+			marker := "  "
+			if prompt == m.promptName {
+				marker = "* "
+			}
+			sb.WriteString(marker + prompt + "\n")
+		}
+
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: strings.TrimRight(sb.String(), "\n"),
+		})
+		return m.updateViewportContent()
+	}
+
+	prompt, err := m.prompts.Render(name)
+	if err != nil {
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: ErrorStyle.Render(err.Error()),
+		})
+		return m.updateViewportContent()
+	}
+
+	m.agent.SetSystemPrompt(prompt)
+	m.promptName = name
+	m.messages = append(m.messages, domain.Message{
+		Role:    domain.RoleNotice,
+		Content: fmt.Sprintf("System prompt switched to %s.", CommandStyle.Render(name)),
+	})
+	return m.updateViewportContent()
+}
+
+// requestCommand applies the command policy and the risk analysis before asking for confirmation.
+// Denied commands are never executed and allowed ones skip the confirmation unless they are high risk.
+// suggestedBy is the answer the command was taken from, recorded in the audit log
+func (m *ChatModel) requestCommand(command, suggestedBy string) tea.Cmd {
+	m.pendingCommand = command
+	m.pendingSuggestedBy = suggestedBy
+	m.pendingRisk = domain.RiskReport{}
+	m.pendingPolicy = domain.PolicyDecision{Action: domain.PolicyConfirm}
+
+	if m.policy != nil {
+		m.pendingPolicy = m.policy.Evaluate(command)
+	}
+
+	if m.pendingPolicy.Action == domain.PolicyDeny {
+		reason := m.pendingPolicy.Reason
+		if reason == "" {
+			reason = "matched " + m.pendingPolicy.Rule
+		}
+
+		m.replyToAgent(domain.CommandResult{Reason: "denied by the command policy: " + reason})
+		m.messages = append(m.messages, domain.Message{
+			Role:    domain.RoleNotice,
+			Content: ErrorStyle.Render(fmt.Sprintf("Command denied by policy: %s (%s)", m.pendingPolicy.Command, reason)),
+		})
+		return m.updateViewportContent()
+	}
+
+	if m.analyzer != nil {
+		m.pendingRisk = m.analyzer.Analyze(command)
+	}
+
+	if m.pendingPolicy.Action == domain.PolicyAllow && m.pendingRisk.Level < domain.RiskHigh {
+		return m.executeCommand(command)
+	}
+
+	m.enterConfirmMode()
+
+	m.messages = append(m.messages, domain.Message{
+		Role:    domain.RoleSystem,
+		Content: fmt.Sprintf("Do you want to execute: %s", CommandStyle.Render(command)),
+	})
+	return m.updateViewportContent()
+}
+
+func (m *ChatModel) enterConfirmMode() {
+	m.confirmMode = true
+
+	answer := "y/n"
+	if m.pendingRisk.Level >= domain.RiskHigh {
+		answer = "type 'yes' to execute this high risk command"
+	}
+	if m.sandbox != nil {
+		answer += ", d for a sandboxed dry-run"
+	}
+
+	m.composer.Placeholder = fmt.Sprintf("Execute command? (%s)", answer)
+}
+
+// dryRunCommand runs the command in the sandbox so its file changes can be reviewed before executing it
+func (m *ChatModel) dryRunCommand(command string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := m.sandbox.DryRun(command)
+		return DryRunMsg{Command: command, Result: result, Err: err}
+	}
+}
+
+// replyToAgent sends the result of a command proposed by the agent back to it, if one is pending
+func (m *ChatModel) replyToAgent(result domain.CommandResult) {
+	if m.pendingReply == nil {
+		return
+	}
+
+	m.pendingReply <- result
+	m.pendingReply = nil
+	m.pendingReason = ""
+}
+
 func (m *ChatModel) executeCommand(command string) tea.Cmd {
+	suggestedBy := m.pendingSuggestedBy
+	m.pendingSuggestedBy = ""
+
+	reply := m.pendingReply
+	if reply != nil {
+		suggestedBy = "execute_command tool call: " + m.pendingReason
+		m.pendingReply = nil
+		m.pendingReason = ""
+	}
+
+	// Another session may be opened while the command runs, its entry belongs to this one
+	auditLogger := m.auditLogger
+	sessionID := m.sessionID
+
 	return func() tea.Msg {
+		startedAt := time.Now()
 		cmd := exec.Command("sh", "-c", command)
 		output, err := cmd.CombinedOutput()
+		duration := time.Since(startedAt)
+
+		exitCode := 0
 		if err != nil {
-			return CommandOutputMsg(fmt.Sprintf("Error executing command: %v\n%s", err, output))
+			exitCode = -1
+
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				exitCode = exitErr.ExitCode()
+			}
+		}
+
+		auditNote := ""
+		if auditLogger != nil {
+			cwd, _ := os.Getwd()
+
+			auditErr := auditLogger.Append(domain.AuditEntry{
+				Timestamp:   startedAt,
+				User:        currentUser(),
+				Cwd:         cwd,
+				Command:     command,
+				ExitCode:    exitCode,
+				DurationMs:  duration.Milliseconds(),
+				SessionID:   sessionID,
+				SuggestedBy: suggestedBy,
+			})
+			if auditErr != nil {
+				auditNote = fmt.Sprintf("\nWarning: could not write the audit log: %v", auditErr)
+			}
+		}
+
+		if reply != nil {
+			reply <- domain.CommandResult{
+				Approved: true,
+				ExitCode: exitCode,
+				Output:   string(output),
+			}
+		}
+
+		if err != nil {
+			return CommandOutputMsg(fmt.Sprintf("Error executing command: %v\n%s%s", err, output, auditNote))
+		}
+
+		return CommandOutputMsg(fmt.Sprintf("Command output:\n%s%s", output, auditNote))
+	}
+}
+
+// addUsage adds the usage of an answer to the running total of the session
+func (m *ChatModel) addUsage(usage *domain.Usage) {
+	if usage == nil {
+		return
+	}
+
+	m.usage.PromptTokens += usage.PromptTokens
+	m.usage.CompletionTokens += usage.CompletionTokens
+	m.usage.TotalTokens += usage.TotalTokens
+	m.usage.Cost += usage.Cost
+}
+
+// noteProvider tells the user when a fallback provider answered instead of the configured one
+func (m *ChatModel) noteProvider(usage *domain.Usage) {
+	if usage == nil || usage.Provider == "" {
+		return
+	}
+
+	m.answeredBy = usage.Provider
+	if usage.Model != "" {
+		m.answeredBy += "/" + usage.Model
+	}
+
+	if m.provider == "" || (usage.Provider == m.provider && usage.Model == m.model) {
+		return
+	}
+
+	m.messages = append(m.messages, domain.Message{
+		Role:    domain.RoleNotice,
+		Content: WarningStyle.Render(fmt.Sprintf("%s was unavailable, answered by %s", m.provider, m.answeredBy)),
+	})
+}
+
+// saveSession stores the conversation so it can be reopened and aggregated by "how usage"
+func (m *ChatModel) saveSession() {
+	if m.sessionStore == nil {
+		return
+	}
+
+	m.linkMessages()
+
+	err := m.sessionStore.Save(domain.Session{
+		ID:         m.sessionID,
+		Title:      m.sessionTitle,
+		Tags:       m.sessionTags,
+		CreatedAt:  m.startedAt,
+		UpdatedAt:  time.Now(),
+		Messages:   withoutNotices(m.messages),
+		Branches:   m.branches,
+		TitleUsage: m.titleUsage,
+	})
+	if err != nil {
+		m.error = fmt.Sprintf("Error: could not save the session: %v", err)
+		m.errorHint = ""
+	}
+}
+
+// titleSession asks for a title and tags for the session once it has an answer and no title yet
+func (m *ChatModel) titleSession() tea.Cmd {
+	if m.titler == nil || m.sessionTitle != "" || m.titling {
+		return nil
+	}
+
+	m.titling = true
+	titler := m.titler
+	sessionID := m.sessionID
+	messages := slices.Clone(m.messages)
+
+	return func() tea.Msg {
+		summary, err := titler.TitleSession(context.Background(), messages)
+		return SessionSummaryMsg{Summary: summary, Err: err, sessionID: sessionID}
+	}
+}
+
+// addTitleUsage counts the tokens used to title a session in it. The session is updated in the store
+// when another one was opened meanwhile
+func (m *ChatModel) addTitleUsage(sessionID string, usage domain.Usage) {
+	if sessionID == m.sessionID {
+		m.titleUsage = append(m.titleUsage, usage)
+		m.addUsage(&usage)
+		m.saveSession()
+		return
+	}
+
+	if m.sessionStore == nil {
+		return
+	}
+
+	stored, err := m.sessionStore.Load(sessionID)
+	if err != nil {
+		return
+	}
+
+	stored.TitleUsage = append(stored.TitleUsage, usage)
+	if err := m.sessionStore.Save(stored); err != nil {
+		m.error, m.errorHint = fmt.Sprintf("Error: could not save the session: %v", err), ""
+	}
+}
+
+// statusBar shows the session and the tokens and cost used so far
+func (m *ChatModel) statusBar() string {
+	status := fmt.Sprintf(
+		"Session %s · %d tokens (%d in, %d out)",
+		m.sessionID[:8],
+		m.usage.TotalTokens,
+		m.usage.PromptTokens,
+		m.usage.CompletionTokens,
+	)
+
+	if m.usage.Cost > 0 {
+		status += fmt.Sprintf(" · $%.4f", m.usage.Cost)
+	}
+
+	if m.answeredBy != "" {
+		status += " · " + m.answeredBy
+	}
+
+	if time.Since(m.quitPressedAt) < quitConfirmWindow {
+		return WarningStyle.Render(fmt.Sprintf("Press %s again to quit", m.keys.quitKey()))
+	}
+
+	if m.editing >= 0 {
+		status += fmt.Sprintf(" · editing prompt %d, %s to cancel", m.promptNumber(m.editing), m.keys.Cancel.Help().Key)
+	} else if m.waitingForAI {
+		status += fmt.Sprintf(" · %s to cancel", m.keys.Cancel.Help().Key)
+	} else if m.keys.Help.Enabled() {
+		status += fmt.Sprintf(" · %s for help", m.keys.Help.Help().Key)
+	}
+
+	return StatusStyle.Render(status)
+}
+
+// lastAssistantMessage returns the content of the most recent assistant message, if any
+func (m *ChatModel) lastAssistantMessage() string {
+	for idx := len(m.messages) - 1; idx >= 0; idx-- {
+		if m.messages[idx].Role == domain.RoleAssistant {
+			return m.messages[idx].Content
 		}
+	}
+
+	return ""
+}
 
-		return CommandOutputMsg(fmt.Sprintf("Command output:\n%s", output))
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
 	}
+
+	return os.Getenv("USER")
 }
 
+// updateViewportContent renders the conversation in the background. Answers already rendered at the
+// current width come from the cache, so only new ones are rendered
 func (m *ChatModel) updateViewportContent() tea.Cmd {
+	m.linkMessages()
+
+	m.contentVersion++
+	version := m.contentVersion
+	messages := slices.Clone(m.messages)
+	counts, positions := siblings(m.messages, m.branches)
+	streaming := m.streaming
+	width := m.viewport.Width
+	renderer := m.renderer
+
+	// Messages that are not markdown are wrapped to the viewport, which would cut them otherwise
+	wrap := func(s string) string {
+		if width <= 0 {
+			return s
+		}
+		return ansi.Wrap(s, width, "")
+	}
+
 	return func() tea.Msg {
 		var content strings.Builder
+		lines := 0
+		write := func(s string) {
+			content.WriteString(s)
+			lines += strings.Count(s, "\n")
+		}
+		offsets := make([]int, len(messages))
+
+		// The welcome banner is only displayed, it is not part of the conversation sent to the model
+		write(wrap(welcomeMessage) + "\n\n")
+
+		// Only the code blocks of the last answer are numbered, they are the ones /copy and /run refer to
+		lastAnswer := -1
+		for idx, msg := range messages {
+			if msg.Role == domain.RoleAssistant {
+				lastAnswer = idx
+			}
+		}
+
+		// Prompts are numbered for /edit, and the messages where the conversation branches show which branch is shown
+		prompt := 0
+		for idx, msg := range messages {
+			offsets[idx] = lines
+
+			marker := ""
+			if counts[msg.ParentID] > 1 {
+				marker = fmt.Sprintf(" ‹%d/%d›", positions[msg.ID], counts[msg.ParentID])
+			}
 
-		for _, msg := range m.messages {
 			switch msg.Role {
 			case domain.RoleUser:
-				content.WriteString(UserStyle.Render("You: ") + msg.Content + "\n")
+				prompt++
+				write(wrap(UserStyle.Render(fmt.Sprintf("You [%d]%s: ", prompt, marker))+msg.Content) + "\n")
 			case domain.RoleAssistant:
-				rendered, err := m.renderer.Render(msg.Content)
-				if err != nil {
-					content.WriteString(AssistantStyle.Render("How: ") + msg.Content + "\n")
+				markdown := msg.Content
+				if idx == lastAnswer {
+					markdown = numberCodeBlocks(markdown)
 				}
 
-				content.WriteString(AssistantStyle.Render("How: ") + rendered + "\n")
-			case domain.RoleSystem:
-				content.WriteString(msg.Content + "\n\n")
+				write(AssistantStyle.Render("How"+marker+": ") + renderer.render(markdown, true) + "\n")
+				if msg.Interrupted {
+					write(WarningStyle.Render("(interrupted)") + "\n\n")
+				}
+				if blocks, _ := parseCodeBlocks(msg.Content); idx == lastAnswer && len(blocks) > 0 {
+					write(wrap(InfoStyle.Render("/copy N copies code block N, /run N runs it")) + "\n\n")
+				}
+			case domain.RoleSystem, domain.RoleNotice:
+				write(wrap(msg.Content) + "\n\n")
+			case domain.RoleError:
+				write(wrap(ErrorStyle.Render(msg.Content)) + "\n\n")
 			}
 		}
 
-		return ViewportContentMsg(content.String())
+		if streaming != "" {
+			write(AssistantStyle.Render("How: ") + renderer.render(streaming, false) + "\n")
+		}
+
+		return ViewportContentMsg{Content: content.String(), version: version, offsets: offsets}
 	}
 }
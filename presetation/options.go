@@ -0,0 +1,138 @@
+package presetation
+
+import (
+	"fmt"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+// Option configures the chat model before the program starts
+type Option func(m *ChatModel)
+
+// WithAnalyzer sets the analyzer used to classify commands before the confirm prompt
+func WithAnalyzer(analyzer domain.CommandAnalyzer) Option {
+	return func(m *ChatModel) {
+		m.analyzer = analyzer
+	}
+}
+
+// WithPolicy sets the policy evaluated before a command is executed
+func WithPolicy(policy domain.CommandPolicy) Option {
+	return func(m *ChatModel) {
+		m.policy = policy
+	}
+}
+
+// WithAuditLogger sets the logger every executed command is recorded in
+func WithAuditLogger(logger domain.AuditLogger) Option {
+	return func(m *ChatModel) {
+		m.auditLogger = logger
+	}
+}
+
+// WithSandbox sets the sandbox used to preview a command with a dry-run before executing it
+func WithSandbox(sandbox domain.Sandbox) Option {
+	return func(m *ChatModel) {
+		m.sandbox = sandbox
+	}
+}
+
+// WithClipboard sets the clipboard /copy copies the code blocks of the answers to
+func WithClipboard(clipboard domain.Clipboard) Option {
+	return func(m *ChatModel) {
+		m.clipboard = clipboard
+	}
+}
+
+// WithTerminal draws the chat on the terminal, shared with the clipboard so their writes do not interleave
+func WithTerminal(terminal *Terminal) Option {
+	return func(m *ChatModel) {
+		m.terminal = terminal
+	}
+}
+
+// WithCommandBroker lets the commands proposed by the agent through the broker go through the confirm flow
+func WithCommandBroker(broker *CommandBroker) Option {
+	return func(m *ChatModel) {
+		m.broker = broker
+	}
+}
+
+// WithPrompts sets the system prompts the /system command switches between and the name of the current one
+func WithPrompts(library domain.PromptLibrary, current string) Option {
+	return func(m *ChatModel) {
+		m.prompts = library
+		m.promptName = current
+	}
+}
+
+// WithSessionStore saves the conversation in the store after every answer and command output
+func WithSessionStore(store domain.SessionStore) Option {
+	return func(m *ChatModel) {
+		m.sessionStore = store
+	}
+}
+
+// WithSearcher searches the messages and commands of the saved sessions from the session browser
+func WithSearcher(searcher domain.Searcher) Option {
+	return func(m *ChatModel) {
+		m.searcher = searcher
+		m.sessions.fullText = searcher != nil
+	}
+}
+
+// WithSession continues a saved session, showing it from the given message or from its end when focus is -1
+func WithSession(session domain.Session, focus int) Option {
+	return func(m *ChatModel) {
+		m.loadSession(session, focus)
+	}
+}
+
+// WithSessionTitler titles and tags each session after its first answer
+func WithSessionTitler(titler domain.SessionTitler) Option {
+	return func(m *ChatModel) {
+		m.titler = titler
+	}
+}
+
+// WithProvider sets the provider and model expected to answer, so answers from a fallback provider are noted
+func WithProvider(provider, model string) Option {
+	return func(m *ChatModel) {
+		m.provider = provider
+		m.model = model
+	}
+}
+
+// WithHistory loads the prompts sent in previous sessions, recalled with Up/Down and Ctrl-R, and saves the new ones
+func WithHistory(store domain.PromptHistory) Option {
+	return func(m *ChatModel) {
+		m.historyStore = store
+
+		entries, err := store.Entries()
+		if err != nil {
+			m.error = fmt.Sprintf("Error: could not load the prompt history: %v", err)
+			return
+		}
+
+		m.history = newPromptHistory(entries)
+	}
+}
+
+// WithTheme sets the colours of the interface and the style of the rendered answers
+func WithTheme(theme Theme) Option {
+	return func(m *ChatModel) {
+		applyTheme(theme)
+		m.spinner.Style = SpinnerStyle
+
+		if err := m.renderer.setTheme(theme); err != nil {
+			m.error = fmt.Sprintf("Error: could not load the markdown style %q: %v", theme.Markdown, err)
+		}
+	}
+}
+
+// WithKeyMap sets the key bindings of the chat
+func WithKeyMap(keys KeyMap) Option {
+	return func(m *ChatModel) {
+		m.setKeyMap(keys)
+	}
+}
@@ -0,0 +1,565 @@
+package presetation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/google/uuid"
+
+	"github.com/antunesgabriel/how/domain"
+)
+
+type sidebarMode int
+
+const (
+	sidebarBrowse sidebarMode = iota
+	sidebarSearch
+	sidebarRename
+	sidebarDelete
+)
+
+// maxSearchResults is the number of search results listed in the session browser
+const maxSearchResults = 50
+
+// sessionBrowser is the side panel listing the saved sessions, to open, rename and delete them
+type sessionBrowser struct {
+	open     bool
+	mode     sidebarMode
+	sessions []domain.Session
+	// matches are the indexes of the sessions matching the query, in the order of sessions
+	matches []int
+	// results are the full-text matches of the query, listed instead of the sessions when searching is available
+	results  []domain.SearchResult
+	fullText bool
+	cursor   int
+	query    string
+	input    textinput.Model
+}
+
+func newSessionBrowser() sessionBrowser {
+	input := textinput.New()
+	input.Prompt = ""
+
+	return sessionBrowser{input: input}
+}
+
+// setSessions replaces the listed sessions, keeping the query
+func (b *sessionBrowser) setSessions(sessions []domain.Session) {
+	b.sessions = sessions
+	b.filter(b.query)
+}
+
+// filter lists the sessions whose title, tags, provider or messages contain the query, ignoring case
+func (b *sessionBrowser) filter(query string) {
+	b.query = query
+	b.matches = b.matches[:0]
+	if strings.TrimSpace(query) == "" {
+		b.results = nil
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	for idx, session := range b.sessions {
+		if query == "" || sessionMatches(session, query) {
+			b.matches = append(b.matches, idx)
+		}
+	}
+
+	b.cursor = min(b.cursor, max(b.count()-1, 0))
+}
+
+// setResults shows the results of a search, unless the query changed since it started
+func (b *sessionBrowser) setResults(query string, results []domain.SearchResult) {
+	if query != b.query {
+		return
+	}
+
+	b.results = results
+	b.cursor = min(b.cursor, max(b.count()-1, 0))
+}
+
+// showsResults reports whether the search results are listed instead of the sessions
+func (b *sessionBrowser) showsResults() bool {
+	return b.fullText && strings.TrimSpace(b.query) != ""
+}
+
+// count returns the number of listed results or sessions
+func (b *sessionBrowser) count() int {
+	if b.showsResults() {
+		return len(b.results)
+	}
+
+	return len(b.matches)
+}
+
+func sessionMatches(session domain.Session, query string) bool {
+	if strings.Contains(strings.ToLower(sessionTitle(session)), query) ||
+		strings.Contains(strings.ToLower(sessionProvider(session)), query) {
+		return true
+	}
+
+	for _, tag := range session.Tags {
+		if strings.Contains(tag, strings.TrimPrefix(query, "#")) {
+			return true
+		}
+	}
+
+	for _, msg := range session.Messages {
+		if (msg.Role == domain.RoleUser || msg.Role == domain.RoleAssistant) && strings.Contains(strings.ToLower(msg.Content), query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selected returns the session under the cursor, which for a search result is the session it was found in
+func (b *sessionBrowser) selected() (domain.Session, bool) {
+	if b.count() == 0 {
+		return domain.Session{}, false
+	}
+
+	if !b.showsResults() {
+		return b.sessions[b.matches[b.cursor]], true
+	}
+
+	for _, session := range b.sessions {
+		if session.ID == b.results[b.cursor].SessionID {
+			return session, true
+		}
+	}
+
+	return domain.Session{}, false
+}
+
+// selectedMessage returns the message of the search result under the cursor, -1 when there is none
+func (b *sessionBrowser) selectedMessage() int {
+	if !b.showsResults() || b.count() == 0 {
+		return -1
+	}
+
+	return b.results[b.cursor].Message
+}
+
+func (b *sessionBrowser) move(delta int) {
+	if b.count() == 0 {
+		return
+	}
+
+	b.cursor = min(max(b.cursor+delta, 0), b.count()-1)
+}
+
+// edit starts typing the query or the new title in the input
+func (b *sessionBrowser) edit(mode sidebarMode, value string) tea.Cmd {
+	b.mode = mode
+	b.input.SetValue(value)
+	b.input.CursorEnd()
+	return b.input.Focus()
+}
+
+func (b *sessionBrowser) browse() {
+	b.mode = sidebarBrowse
+	b.input.Blur()
+}
+
+// sessionTitle returns the title given to the session or, without one, the start of its first prompt
+func sessionTitle(session domain.Session) string {
+	if session.Title != "" {
+		return session.Title
+	}
+
+	for _, msg := range session.Messages {
+		if msg.Role == domain.RoleUser {
+			line, _, _ := strings.Cut(strings.TrimSpace(msg.Content), "\n")
+			return line
+		}
+	}
+
+	return "Empty session"
+}
+
+// sessionProvider returns the provider and model of the last answer of the session
+func sessionProvider(session domain.Session) string {
+	for idx := len(session.Messages) - 1; idx >= 0; idx-- {
+		usage := session.Messages[idx].Usage
+		if usage == nil || usage.Provider == "" {
+			continue
+		}
+
+		if usage.Model != "" {
+			return usage.Provider + "/" + usage.Model
+		}
+		return usage.Provider
+	}
+
+	return ""
+}
+
+// sidebarWidth is the width of the session browser, zero while it is closed
+func (m *ChatModel) sidebarWidth() int {
+	if !m.sessions.open {
+		return 0
+	}
+
+	return min(max(m.width/3, 24), 40)
+}
+
+// toggleSessions opens the session browser, loading the sessions again, or closes it
+func (m *ChatModel) toggleSessions() tea.Cmd {
+	if m.sessions.open {
+		m.sessions.open = false
+		m.sessions.browse()
+		return m.relayout()
+	}
+
+	if m.sessionStore == nil {
+		m.error, m.errorHint = "Error: sessions are not saved, there is nothing to browse", ""
+		return nil
+	}
+
+	m.sessions.open = true
+	m.sessions.browse()
+	return tea.Batch(m.relayout(), m.loadSessions())
+}
+
+// searchFromChat opens the session browser with the results of the query, or ready to type one
+func (m *ChatModel) searchFromChat(query string) tea.Cmd {
+	var cmds []tea.Cmd
+	if !m.sessions.open {
+		cmds = append(cmds, m.toggleSessions())
+		if !m.sessions.open {
+			return tea.Batch(cmds...)
+		}
+	}
+
+	if query == "" {
+		return tea.Batch(append(cmds, m.sessions.edit(sidebarSearch, m.sessions.query))...)
+	}
+
+	m.sessions.browse()
+	m.sessions.input.SetValue(query)
+	return tea.Batch(append(cmds, m.searchSessions(query))...)
+}
+
+// searchSessions filters the sessions with the query and, when full-text search is available,
+// searches their messages and commands in the background
+func (m *ChatModel) searchSessions(query string) tea.Cmd {
+	m.sessions.filter(query)
+
+	if m.searcher == nil || strings.TrimSpace(query) == "" {
+		return nil
+	}
+
+	searcher := m.searcher
+	return func() tea.Msg {
+		results, err := searcher.Search(query, maxSearchResults)
+		return SearchResultsMsg{Results: results, Err: err, query: query}
+	}
+}
+
+func (m *ChatModel) loadSessions() tea.Cmd {
+	store := m.sessionStore
+
+	return func() tea.Msg {
+		sessions, err := store.List()
+		return SessionsMsg{Sessions: sessions, Err: err}
+	}
+}
+
+// updateSessions handles the keys while the session browser is open. By default the list moves with the
+// arrows or j/k, enter opens the session, / searches, r renames and d deletes after a confirmation
+func (m *ChatModel) updateSessions(msg tea.KeyMsg) tea.Cmd {
+	b := &m.sessions
+
+	switch b.mode {
+	case sidebarSearch, sidebarRename:
+		switch {
+		case key.Matches(msg, m.keys.Cancel):
+			if b.mode == sidebarSearch {
+				b.filter("")
+			}
+			b.browse()
+			return nil
+		case key.Matches(msg, m.keys.Send):
+			value := strings.TrimSpace(b.input.Value())
+			mode := b.mode
+			b.browse()
+
+			if mode == sidebarRename {
+				return m.renameSession(value)
+			}
+			return nil
+		// Letters are typed in the query, so only the other keys move through the results
+		case b.mode == sidebarSearch && msg.Type != tea.KeyRunes && key.Matches(msg, m.keys.SessionUp):
+			b.move(-1)
+			return nil
+		case b.mode == sidebarSearch && msg.Type != tea.KeyRunes && key.Matches(msg, m.keys.SessionDown):
+			b.move(1)
+			return nil
+		}
+
+		var cmd tea.Cmd
+		b.input, cmd = b.input.Update(msg)
+		if b.mode == sidebarSearch && b.input.Value() != b.query {
+			return tea.Batch(cmd, m.searchSessions(b.input.Value()))
+		}
+		return cmd
+
+	case sidebarDelete:
+		b.browse()
+		if key.Matches(msg, m.keys.ConfirmDelete) {
+			return m.deleteSession()
+		}
+		return nil
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Sessions, m.keys.Cancel):
+		if b.query != "" && key.Matches(msg, m.keys.Cancel) {
+			b.filter("")
+			return nil
+		}
+		return m.toggleSessions()
+	case key.Matches(msg, m.keys.Send):
+		return m.openSession()
+	case key.Matches(msg, m.keys.SessionUp):
+		b.move(-1)
+	case key.Matches(msg, m.keys.SessionDown):
+		b.move(1)
+	case key.Matches(msg, m.keys.SessionSearch):
+		return b.edit(sidebarSearch, b.query)
+	case key.Matches(msg, m.keys.SessionRename):
+		if session, ok := b.selected(); ok {
+			return b.edit(sidebarRename, session.Title)
+		}
+	case key.Matches(msg, m.keys.SessionDelete):
+		if _, ok := b.selected(); ok {
+			b.mode = sidebarDelete
+		}
+	}
+
+	return nil
+}
+
+// openSession replaces the conversation with the selected session, which the next prompts continue.
+// A search result opens the session at the matching message
+func (m *ChatModel) openSession() tea.Cmd {
+	session, ok := m.sessions.selected()
+	if !ok {
+		if m.sessions.count() > 0 {
+			m.error, m.errorHint = "Error: the session of this command was not saved", ""
+		}
+		return nil
+	}
+
+	if m.confirmMode {
+		m.error, m.errorHint = "Error: answer the pending command before opening another session", ""
+		return nil
+	}
+
+	if m.waitingForAI {
+		m.interruptAIResponse()
+	}
+
+	m.loadSession(session, m.sessions.selectedMessage())
+	m.error, m.errorHint = "", ""
+
+	m.sessions.open = false
+	m.sessions.browse()
+	return m.relayout()
+}
+
+// loadSession makes the session the current conversation, shown from the focused message or from its end when focus is -1
+func (m *ChatModel) loadSession(session domain.Session, focus int) {
+	m.sessionID = session.ID
+	m.sessionTitle = session.Title
+	m.sessionTags = session.Tags
+	m.startedAt = session.CreatedAt
+	m.messages = session.Messages
+	m.branches = session.Branches
+	m.titleUsage = session.TitleUsage
+	m.editing = -1
+	m.focusMessage = focus
+
+	// The answers of the other branches and the title were paid for too
+	m.usage = domain.Usage{}
+	for _, msg := range append(slices.Clone(m.branches), m.messages...) {
+		m.addUsage(msg.Usage)
+	}
+	for _, usage := range m.titleUsage {
+		m.addUsage(&usage)
+	}
+	m.answeredBy = sessionProvider(session)
+}
+
+// renameSession gives the selected session a title. Without a title the start of its first prompt is shown
+// until the session is titled again after its next answer
+func (m *ChatModel) renameSession(title string) tea.Cmd {
+	session, ok := m.sessions.selected()
+	if !ok {
+		return nil
+	}
+
+	// The listed session may be older than the stored one, so the current session is saved from the chat
+	// and the others are loaded again
+	if session.ID == m.sessionID {
+		m.sessionTitle = title
+		m.saveSession()
+		return m.loadSessions()
+	}
+
+	stored, err := m.sessionStore.Load(session.ID)
+	if err != nil {
+		m.error, m.errorHint = fmt.Sprintf("Error: could not rename the session: %v", err), ""
+		return nil
+	}
+
+	stored.Title = title
+	if err := m.sessionStore.Save(stored); err != nil {
+		m.error, m.errorHint = fmt.Sprintf("Error: could not rename the session: %v", err), ""
+		return nil
+	}
+
+	return m.loadSessions()
+}
+
+// deleteSession removes the selected session. Deleting the current one starts a new conversation
+func (m *ChatModel) deleteSession() tea.Cmd {
+	session, ok := m.sessions.selected()
+	if !ok {
+		return nil
+	}
+
+	// Stopping the answer saves the session, so it is stopped before the session is deleted
+	if session.ID == m.sessionID && m.waitingForAI {
+		m.interruptAIResponse()
+	}
+
+	if err := m.sessionStore.Delete(session.ID); err != nil {
+		m.error, m.errorHint = fmt.Sprintf("Error: could not delete the session: %v", err), ""
+		return nil
+	}
+
+	if session.ID != m.sessionID {
+		return m.loadSessions()
+	}
+
+	m.sessionID = uuid.NewString()
+	m.sessionTitle = ""
+	m.sessionTags = nil
+	m.startedAt = time.Now()
+	m.messages = nil
+	m.branches = nil
+	m.titleUsage = nil
+	m.editing = -1
+	m.usage = domain.Usage{}
+	m.answeredBy = ""
+
+	return tea.Batch(m.loadSessions(), m.updateViewportContent())
+}
+
+// sidebarView renders the session browser with the given size
+func (m *ChatModel) sidebarView(width, height int) string {
+	b := &m.sessions
+	inner := max(width-SidebarStyle.GetHorizontalFrameSize(), 1)
+	b.input.Width = max(inner-len(" / "), 1)
+
+	lines := []string{TitleStyle.UnsetMarginLeft().Render("Sessions")}
+
+	switch b.mode {
+	case sidebarSearch:
+		lines = append(lines, "/ "+b.input.View())
+	case sidebarRename:
+		lines = append(lines, "Title: "+b.input.View())
+	case sidebarDelete:
+		lines = append(lines, WarningStyle.UnsetMarginLeft().Render(fmt.Sprintf("Delete this session? %s/n", m.keys.ConfirmDelete.Help().Key)))
+	default:
+		if b.query != "" {
+			lines = append(lines, "/ "+b.query)
+		} else {
+			lines = append(lines, "")
+		}
+	}
+
+	hint := StatusStyle.UnsetMarginLeft().Render(ansi.Wrap(fmt.Sprintf(
+		"%s open · %s search · %s rename · %s delete",
+		m.keys.Send.Help().Key,
+		m.keys.SessionSearch.Help().Key,
+		m.keys.SessionRename.Help().Key,
+		m.keys.SessionDelete.Help().Key,
+	), inner, ""))
+
+	// Every entry takes four lines, as many as fit are shown around the cursor
+	visible := max((height-len(lines)-lipgloss.Height(hint)-1)/4, 1)
+	first := max(min(b.cursor-visible/2, b.count()-visible), 0)
+
+	switch {
+	case b.showsResults() && b.count() == 0:
+		lines = append(lines, StatusStyle.UnsetMarginLeft().Render("No matches"))
+	case b.count() == 0:
+		lines = append(lines, StatusStyle.UnsetMarginLeft().Render("No sessions"))
+	}
+
+	entry := func(idx int, title, details, extra string) {
+		title = ansi.Truncate(title, inner-2, "…")
+		if idx == b.cursor {
+			title = SelectedStyle.Render("▌ " + title)
+		} else {
+			title = "  " + title
+		}
+
+		lines = append(
+			lines,
+			title,
+			"  "+StatusStyle.UnsetMarginLeft().Render(ansi.Truncate(details, inner-2, "…")),
+			"  "+InfoStyle.UnsetMarginLeft().Render(ansi.Truncate(extra, inner-2, "…")),
+			"",
+		)
+	}
+
+	for idx := first; idx < min(first+visible, b.count()); idx++ {
+		if b.showsResults() {
+			result := b.results[idx]
+
+			title := result.Title
+			if title == "" {
+				title = "Session " + result.SessionID[:min(len(result.SessionID), 8)]
+			}
+
+			entry(idx, title, result.Time.Local().Format("Jan 02 15:04")+" · "+result.Role, result.Snippet)
+			continue
+		}
+
+		session := b.sessions[b.matches[idx]]
+
+		details := session.UpdatedAt.Local().Format("Jan 02 15:04")
+		if provider := sessionProvider(session); provider != "" {
+			details += " · " + provider
+		}
+		if session.ID == m.sessionID {
+			details = "● " + details
+		}
+
+		tags := make([]string, len(session.Tags))
+		for idx, tag := range session.Tags {
+			tags[idx] = "#" + tag
+		}
+
+		entry(idx, sessionTitle(session), details, strings.Join(tags, " "))
+	}
+
+	list := strings.Join(lines, "\n")
+	gap := max(height-lipgloss.Height(list)-lipgloss.Height(hint), 0)
+
+	return SidebarStyle.
+		Width(width - SidebarStyle.GetHorizontalBorderSize()).
+		Height(height).
+		MaxHeight(height).
+		Render(list + strings.Repeat("\n", gap+1) + hint)
+}
@@ -36,6 +36,28 @@ var (
 			Background(lipgloss.Color("#2A2A2A")).
 			Padding(0, 1)
 
+	WarningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFA500")).
+			MarginLeft(2)
+
+	StatusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6C6C6C")).
+			MarginLeft(2)
+
+	HelpStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#6C6C6C")).
+			Padding(1, 2)
+
+	SidebarStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, true, false, false).
+			BorderForeground(lipgloss.Color("#6C6C6C")).
+			Padding(0, 1)
+
+	SelectedStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FF75B5"))
+
 	ConfirmStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FFFFFF")).
 			Background(lipgloss.Color("#FF5F00")).
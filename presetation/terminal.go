@@ -0,0 +1,25 @@
+package presetation
+
+import (
+	"os"
+	"sync"
+)
+
+// Terminal is the output the chat is drawn on. Writes are serialized, so what else is written to the
+// terminal, such as the OSC 52 sequence of the clipboard, never lands in the middle of a frame
+type Terminal struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.File.Write(p)
+}
+
+// NewTerminal wraps the file the chat is drawn on
+func NewTerminal(file *os.File) *Terminal {
+	return &Terminal{File: file}
+}
@@ -0,0 +1,136 @@
+package presetation
+
+import (
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme holds the colours of the interface and the glamour style the answers are rendered with.
+// An empty colour keeps the default colour of the terminal
+type Theme struct {
+	Title             string
+	Info              string
+	Prompt            string
+	User              string
+	Assistant         string
+	Command           string
+	CommandBackground string
+	Error             string
+	Warning           string
+	Status            string
+	Confirm           string
+	ConfirmBackground string
+	// Markdown is a glamour standard style name or the path of a JSON style file
+	Markdown string
+}
+
+var builtinThemes = map[string]Theme{
+	"dark": {
+		Title:             "#FF75B5",
+		Info:              "#9A9A9A",
+		Prompt:            "#04B575",
+		User:              "#04B575",
+		Assistant:         "#FF75B5",
+		Command:           "#FFA500",
+		CommandBackground: "#2A2A2A",
+		Error:             "#FF0000",
+		Warning:           "#FFA500",
+		Status:            "#6C6C6C",
+		Confirm:           "#FFFFFF",
+		ConfirmBackground: "#FF5F00",
+		Markdown:          "dark",
+	},
+	"light": {
+		Title:             "#AF005F",
+		Info:              "#5F5F5F",
+		Prompt:            "#00875F",
+		User:              "#00875F",
+		Assistant:         "#AF005F",
+		Command:           "#875F00",
+		CommandBackground: "#EEEEEE",
+		Error:             "#D70000",
+		Warning:           "#AF5F00",
+		Status:            "#767676",
+		Confirm:           "#FFFFFF",
+		ConfirmBackground: "#D75F00",
+		Markdown:          "light",
+	},
+	"high-contrast": {
+		Title:             "#FFFF00",
+		Info:              "#FFFFFF",
+		Prompt:            "#00FF00",
+		User:              "#00FF00",
+		Assistant:         "#00FFFF",
+		Command:           "#000000",
+		CommandBackground: "#FFFF00",
+		Error:             "#FF5555",
+		Warning:           "#FFFF00",
+		Status:            "#FFFFFF",
+		Confirm:           "#000000",
+		ConfirmBackground: "#FFFFFF",
+		Markdown:          "dark",
+	},
+	"monochrome": {
+		Markdown: "notty",
+	},
+}
+
+// BuiltinTheme returns the colours of a built-in theme. Auto, and any unknown name, picks the dark
+// or the light theme following the background of the terminal
+func BuiltinTheme(name string) Theme {
+	if theme, ok := builtinThemes[name]; ok {
+		return theme
+	}
+
+	if lipgloss.HasDarkBackground() {
+		return builtinThemes["dark"]
+	}
+	return builtinThemes["light"]
+}
+
+// applyTheme sets the colours of the package styles. Without colours, emphasis is kept with bold and reverse video
+func applyTheme(t Theme) {
+	TitleStyle = lipgloss.NewStyle().Bold(true).Foreground(color(t.Title)).MarginLeft(2)
+	InfoStyle = lipgloss.NewStyle().Foreground(color(t.Info)).MarginLeft(2)
+	PromptStyle = lipgloss.NewStyle().Foreground(color(t.Prompt)).MarginLeft(2)
+	ErrorStyle = lipgloss.NewStyle().Foreground(color(t.Error)).Bold(t.Error == "").MarginLeft(2)
+	UserStyle = lipgloss.NewStyle().Foreground(color(t.User)).Bold(t.User == "")
+	AssistantStyle = lipgloss.NewStyle().Foreground(color(t.Assistant)).Bold(t.Assistant == "")
+	SpinnerStyle = lipgloss.NewStyle().Foreground(color(t.Assistant))
+	CommandStyle = lipgloss.NewStyle().
+		Foreground(color(t.Command)).
+		Background(color(t.CommandBackground)).
+		Underline(t.CommandBackground == "").
+		Padding(0, 1)
+	WarningStyle = lipgloss.NewStyle().Foreground(color(t.Warning)).MarginLeft(2)
+	StatusStyle = lipgloss.NewStyle().Foreground(color(t.Status)).Faint(t.Status == "").MarginLeft(2)
+	HelpStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(color(t.Status)).Padding(1, 2)
+	SidebarStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, true, false, false).
+		BorderForeground(color(t.Status)).
+		Padding(0, 1)
+	SelectedStyle = lipgloss.NewStyle().Bold(true).Foreground(color(t.Title)).Reverse(t.Title == "")
+	ConfirmStyle = lipgloss.NewStyle().
+		Foreground(color(t.Confirm)).
+		Background(color(t.ConfirmBackground)).
+		Reverse(t.ConfirmBackground == "").
+		Padding(0, 1)
+}
+
+func color(value string) lipgloss.TerminalColor {
+	if value == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(value)
+}
+
+// newRenderer creates the markdown renderer of the answers with the glamour style of the theme,
+// following the terminal background when the theme has none
+func newRenderer(t Theme, width int) (*glamour.TermRenderer, error) {
+	style := glamour.WithAutoStyle()
+	if t.Markdown != "" {
+		style = glamour.WithStylePath(t.Markdown)
+	}
+
+	return glamour.NewTermRenderer(style, glamour.WithWordWrap(width))
+}
@@ -10,18 +10,26 @@ import (
 	"github.com/antunesgabriel/how/domain"
 )
 
-func StartApp(llmAgent domain.Agent, initialQuery string) error {
+func StartApp(llmAgent domain.Agent, initialQuery string, opts ...Option) error {
 	if !term.IsTerminal(int(os.Stdin.Fd())) {
 		return fmt.Errorf("this program requires an interactive terminal")
 	}
 
 	chatModel := NewChatModel(llmAgent)
+	for _, opt := range opts {
+		opt(chatModel)
+	}
 
 	if initialQuery != "" {
 		chatModel.SetInitialQuery(initialQuery)
 	}
 
-	p := tea.NewProgram(chatModel, tea.WithAltScreen())
+	programOpts := []tea.ProgramOption{tea.WithAltScreen()}
+	if chatModel.terminal != nil {
+		programOpts = append(programOpts, tea.WithOutput(chatModel.terminal))
+	}
+
+	p := tea.NewProgram(chatModel, programOpts...)
 	if _, err := p.Run(); err != nil {
 		return fmt.Errorf("error running program: %w", err)
 	}